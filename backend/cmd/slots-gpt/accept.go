@@ -0,0 +1,33 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// wantsPlainText reports whether the Accept header prefers text/plain over
+// JSON. Wildcards count towards JSON, which stays the default.
+func wantsPlainText(r *http.Request) bool {
+	plain, json := 0.0, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case "text/plain":
+			plain = max(plain, q)
+		case "application/json", "*/*", "application/*":
+			json = max(json, q)
+		}
+	}
+	return plain > json
+}
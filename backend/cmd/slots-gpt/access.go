@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// AccessCheckResponse reports whether the configured credentials can use a
+// model.
+type AccessCheckResponse struct {
+	Model      string `json:"model"`
+	Accessible bool   `json:"accessible"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// adminMiddleware requires "Authorization: Bearer <ADMIN_TOKEN>". Admin
+// endpoints are disabled while ADMIN_TOKEN is unset.
+func adminMiddleware(next http.Handler) http.Handler {
+	token := os.Getenv("ADMIN_TOKEN")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeAPIError(w, http.StatusForbidden, "admin_disabled", "Admin endpoints are disabled; set ADMIN_TOKEN to enable them")
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAPIError(w, http.StatusUnauthorized, "unauthorized", "A valid admin bearer token is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// accessCheckHandler looks a model up with GetFoundationModel to tell
+// whether the credentials can see it and it can be invoked on demand,
+// without paying for an invoke. Aliases resolve as in send-prompt.
+func accessCheckHandler(svc *bedrock.Bedrock, profiles map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Invalid request method")
+			return
+		}
+		model := strings.TrimSpace(r.URL.Query().Get("model"))
+		if model == "" {
+			writeAPIError(w, http.StatusBadRequest, "missing_model", `The "model" query parameter is required`)
+			return
+		}
+		modelID, err := resolveModelID(model, profiles)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid_model", err.Error())
+			return
+		}
+
+		response := AccessCheckResponse{Model: modelID}
+		if modelARNPattern.MatchString(modelID) && !strings.Contains(modelID, ":foundation-model/") {
+			response.Reason = "only foundation models can be checked without invoking, not inference profile, provisioned, custom or imported model ARNs"
+			writeAccessCheck(w, response)
+			return
+		}
+
+		out, err := svc.GetFoundationModelWithContext(r.Context(), &bedrock.GetFoundationModelInput{
+			ModelIdentifier: aws.String(modelID),
+		})
+		var aerr awserr.Error
+		switch {
+		case errors.As(err, &aerr) && aerr.Code() == bedrock.ErrCodeAccessDeniedException:
+			response.Reason = "the configured credentials are not allowed to access this model"
+		case errors.As(err, &aerr) && aerr.Code() == bedrock.ErrCodeResourceNotFoundException:
+			response.Reason = "model not found in this region"
+		case err != nil:
+			logf(r.Context(), "Error checking access to %s (aws_request_id=%s): %v", modelID, awsRequestID(err), err)
+			writeError(w, http.StatusInternalServerError, APIError{
+				Code:         "bedrock_error",
+				Message:      "Failed to check model access",
+				AWSRequestID: awsRequestID(err),
+			})
+			return
+		default:
+			response.Accessible, response.Reason = foundationModelUsable(out.ModelDetails)
+		}
+		writeAccessCheck(w, response)
+	}
+}
+
+// foundationModelUsable reports whether a model the credentials can see can
+// be invoked on demand, with a reason when it cannot or is deprecated.
+func foundationModelUsable(details *bedrock.FoundationModelDetails) (bool, string) {
+	if details == nil {
+		return false, "Bedrock returned no model details"
+	}
+	if !slices.Contains(aws.StringValueSlice(details.InferenceTypesSupported), bedrock.InferenceTypeOnDemand) {
+		return false, "model does not support on-demand invocation; map it to an inference profile with INFERENCE_PROFILE_MAP"
+	}
+	if details.ModelLifecycle != nil && aws.StringValue(details.ModelLifecycle.Status) != bedrock.FoundationModelLifecycleStatusActive {
+		return true, fmt.Sprintf("model lifecycle status is %s", aws.StringValue(details.ModelLifecycle.Status))
+	}
+	return true, ""
+}
+
+func writeAccessCheck(w http.ResponseWriter, response AccessCheckResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// modelCapabilities are the limits a request is validated against before
+// invoking the model.
+type modelCapabilities struct {
+	MaxInputTokens int `json:"max_input_tokens"`
+}
+
+// defaultCapabilities covers the common Bedrock text models. Bedrock's
+// ListFoundationModels does not report context sizes, so they are kept here.
+var defaultCapabilities = map[string]modelCapabilities{
+	"anthropic.claude-v2":                     {MaxInputTokens: 100000},
+	"anthropic.claude-v2:1":                   {MaxInputTokens: 200000},
+	"anthropic.claude-instant-v1":             {MaxInputTokens: 100000},
+	"anthropic.claude-3-haiku-20240307-v1:0":  {MaxInputTokens: 200000},
+	"anthropic.claude-3-sonnet-20240229-v1:0": {MaxInputTokens: 200000},
+	"amazon.titan-text-express-v1":            {MaxInputTokens: 8000},
+	"amazon.titan-text-lite-v1":               {MaxInputTokens: 4000},
+	"ai21.j2-mid-v1":                          {MaxInputTokens: 8191},
+	"ai21.j2-ultra-v1":                        {MaxInputTokens: 8191},
+	"cohere.command-text-v14":                 {MaxInputTokens: 4096},
+	"meta.llama2-13b-chat-v1":                 {MaxInputTokens: 4096},
+	"meta.llama2-70b-chat-v1":                 {MaxInputTokens: 4096},
+}
+
+// loadCapabilities returns the default table with MODEL_CAPABILITIES, a JSON
+// object keyed by model ID, merged over it.
+func loadCapabilities(raw string) (map[string]modelCapabilities, error) {
+	capabilities := make(map[string]modelCapabilities, len(defaultCapabilities))
+	for id, c := range defaultCapabilities {
+		capabilities[id] = c
+	}
+	if raw == "" {
+		return capabilities, nil
+	}
+	var overrides map[string]modelCapabilities
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		return nil, fmt.Errorf("invalid MODEL_CAPABILITIES: %w", err)
+	}
+	for id, c := range overrides {
+		capabilities[id] = c
+	}
+	return capabilities, nil
+}
+
+// validatePromptLength rejects prompts whose estimated token count exceeds
+// the model's input limit. Models missing from the table are not checked.
+// Foundation model ARNs are looked up by their model ID.
+func validatePromptLength(capabilities map[string]modelCapabilities, modelID, prompt string) error {
+	if i := strings.Index(modelID, ":foundation-model/"); i >= 0 {
+		modelID = modelID[i+len(":foundation-model/"):]
+	}
+	c, ok := capabilities[modelID]
+	if !ok || c.MaxInputTokens <= 0 {
+		return nil
+	}
+	if tokens := estimateTokens(prompt); tokens > c.MaxInputTokens {
+		return fmt.Errorf("prompt is too long for model %s: about %d tokens, max %d", modelID, tokens, c.MaxInputTokens)
+	}
+	return nil
+}
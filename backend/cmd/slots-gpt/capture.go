@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CapturedExchange is one request/response pair written to CAPTURE_DIR and
+// accepted by /api/replay.
+type CapturedExchange struct {
+	Time     time.Time         `json:"time"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Request  json.RawMessage   `json:"request"`
+	Status   int               `json:"status"`
+	Response string            `json:"response"`
+}
+
+// capturedHeaders are the request headers that change how a prompt is
+// answered, and so are needed to replay it.
+var capturedHeaders = []string{"Accept", "X-API-Version"}
+
+// piiPatterns are scrubbed from captured bodies before they touch disk.
+var piiPatterns = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[REDACTED_EMAIL]"},
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), "[REDACTED_AWS_KEY]"},
+	{regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), "[REDACTED_NUMBER]"},
+}
+
+// capturer writes request/response pairs to a directory for offline
+// reproduction, removing the oldest files beyond the size and count caps.
+// It is disabled when dir is empty.
+type capturer struct {
+	dir      string
+	maxFiles int
+	maxBytes int64
+	secrets  []string
+
+	mu sync.Mutex
+}
+
+func loadCapturer(secrets ...string) (*capturer, error) {
+	c := &capturer{
+		dir:      os.Getenv("CAPTURE_DIR"),
+		maxFiles: envInt("CAPTURE_MAX_FILES", 1000),
+		maxBytes: int64(envInt("CAPTURE_MAX_MB", 100)) << 20,
+		secrets:  secrets,
+	}
+	if c.dir == "" {
+		return c, nil
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create CAPTURE_DIR: %w", err)
+	}
+	log.Printf("Capturing requests and responses to %s", c.dir)
+	return c, nil
+}
+
+// scrub masks configured secrets and common PII in captured text.
+func (c *capturer) scrub(s string) string {
+	for _, secret := range c.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, maskSecret(secret))
+		}
+	}
+	for _, p := range piiPatterns {
+		s = p.re.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+// recordingWriter keeps a copy of the status and body written to the client.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// middleware captures every exchange handled by next. It buffers the whole
+// request body, so it must run inside limitBody.
+func (c *capturer) middleware(next http.Handler) http.Handler {
+	if c.dir == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		var sizeErr *http.MaxBytesError
+		if errors.As(err, &sizeErr) {
+			writeAPIError(w, http.StatusRequestEntityTooLarge, "request_too_large", decodeError(err))
+			return
+		}
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid_request", "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := &recordingWriter{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		exchange := CapturedExchange{
+			Time:     time.Now().UTC(),
+			Path:     r.URL.Path,
+			Headers:  map[string]string{},
+			Status:   rec.status,
+			Response: c.scrub(rec.body.String()),
+		}
+		for _, name := range capturedHeaders {
+			if v := r.Header.Get(name); v != "" {
+				exchange.Headers[name] = v
+			}
+		}
+		scrubbed := c.scrub(string(body))
+		if json.Valid([]byte(scrubbed)) {
+			exchange.Request = json.RawMessage(scrubbed)
+		} else {
+			exchange.Request, _ = json.Marshal(scrubbed)
+		}
+		if err := c.write(exchange); err != nil {
+			logf(r.Context(), "Failed to capture request: %v", err)
+		}
+	})
+}
+
+func (c *capturer) write(exchange CapturedExchange) error {
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := fmt.Sprintf("capture-%s.json", exchange.Time.Format("20060102T150405.000000000"))
+	if err := os.WriteFile(filepath.Join(c.dir, name), data, 0o600); err != nil {
+		return err
+	}
+	return c.rotate()
+}
+
+// rotate deletes the oldest captures until the directory is within both
+// caps. Capture names sort chronologically.
+func (c *capturer) rotate() error {
+	matches, err := filepath.Glob(filepath.Join(c.dir, "capture-*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+
+	sizes := make([]int64, len(matches))
+	var total int64
+	for i, path := range matches {
+		if info, err := os.Stat(path); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+
+	for i := 0; i < len(matches) && (len(matches)-i > c.maxFiles || total > c.maxBytes); i++ {
+		if err := os.Remove(matches[i]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= sizes[i]
+	}
+	return nil
+}
+
+// replayHandler re-runs a CapturedExchange posted to it against handler,
+// answering with the fresh response.
+func replayHandler(handler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Invalid request method")
+			return
+		}
+
+		var exchange CapturedExchange
+		if err := json.NewDecoder(r.Body).Decode(&exchange); err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+		if len(exchange.Request) == 0 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_request", "Captured exchange has no request")
+			return
+		}
+
+		replay := r.Clone(r.Context())
+		replay.Method = http.MethodPost
+		replay.Body = io.NopCloser(bytes.NewReader(exchange.Request))
+		replay.ContentLength = int64(len(exchange.Request))
+		replay.Header.Set("Content-Type", "application/json")
+		for name, v := range exchange.Headers {
+			replay.Header.Set(name, v)
+		}
+		handler.ServeHTTP(w, replay)
+	}
+}
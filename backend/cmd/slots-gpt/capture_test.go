@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCaptureMiddlewareRejectsOversizedBody(t *testing.T) {
+	c := &capturer{dir: t.TempDir(), maxFiles: 10, maxBytes: 1 << 20}
+	var called bool
+	handler := limitBody(10, c.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/send-prompt", strings.NewReader(`{"prompt":"`+strings.Repeat("x", 100)+`"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if called {
+		t.Error("handler called for an oversized body")
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != "request_too_large" {
+		t.Errorf("body = %q, want a request_too_large error", w.Body.String())
+	}
+	if entries, _ := os.ReadDir(c.dir); len(entries) != 0 {
+		t.Errorf("captured %d files, want none", len(entries))
+	}
+}
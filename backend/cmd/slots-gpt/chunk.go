@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+)
+
+const (
+	defaultChunkMapTemplate = `This is part {{.Index}} of {{.Total}} of a longer document. Summarize it, keeping every important detail:
+
+{{.Text}}`
+
+	defaultChunkCombineTemplate = `The following are summaries of consecutive parts of one document. Combine them into a single coherent summary:
+{{range $i, $r := .Results}}
+Part {{inc $i}}:
+{{$r}}
+{{end}}`
+)
+
+// ChunkUsage reports the estimated token usage of one chunk invocation, or
+// of the final combine invocation when Combine is set.
+type ChunkUsage struct {
+	Index        int  `json:"index"`
+	Combine      bool `json:"combine,omitempty"`
+	InputTokens  int  `json:"input_tokens"`
+	OutputTokens int  `json:"output_tokens"`
+}
+
+// chunker processes prompts too long for the context window map-reduce
+// style: each overlapping chunk is invoked separately, then the results are
+// combined by a final invocation.
+type chunker struct {
+	sizeTokens    int
+	overlapTokens int
+	concurrency   int
+	maxChunks     int
+	mapTmpl       *template.Template
+	combineTmpl   *template.Template
+	capabilities  map[string]modelCapabilities
+	limiter       *modelLimiter
+}
+
+// loadChunker reads CHUNK_SIZE_TOKENS, CHUNK_OVERLAP_TOKENS,
+// CHUNK_CONCURRENCY and CHUNK_MAX_CHUNKS, and the CHUNK_MAP_TEMPLATE (over
+// .Index, .Total and .Text) and CHUNK_COMBINE_TEMPLATE (over .Results)
+// prompt templates. Combine prompts are checked against capabilities, and
+// chunks of one request never run more in parallel than limiter allows for
+// the model, so they do not queue behind each other.
+func loadChunker(capabilities map[string]modelCapabilities, limiter *modelLimiter) (*chunker, error) {
+	c := &chunker{
+		sizeTokens:    envInt("CHUNK_SIZE_TOKENS", 4000),
+		overlapTokens: envInt("CHUNK_OVERLAP_TOKENS", 200),
+		concurrency:   envInt("CHUNK_CONCURRENCY", 4),
+		maxChunks:     envInt("CHUNK_MAX_CHUNKS", 20),
+		capabilities:  capabilities,
+		limiter:       limiter,
+	}
+	if c.sizeTokens <= 0 || c.overlapTokens < 0 || c.overlapTokens >= c.sizeTokens {
+		return nil, fmt.Errorf("CHUNK_OVERLAP_TOKENS must be between 0 and CHUNK_SIZE_TOKENS")
+	}
+	if c.maxChunks <= 0 {
+		return nil, fmt.Errorf("CHUNK_MAX_CHUNKS must be positive")
+	}
+	if c.concurrency <= 0 {
+		c.concurrency = 1
+	}
+
+	var err error
+	funcs := template.FuncMap{"inc": func(i int) int { return i + 1 }}
+	if c.mapTmpl, err = parseEnvTemplate("CHUNK_MAP_TEMPLATE", defaultChunkMapTemplate, funcs); err != nil {
+		return nil, err
+	}
+	if c.combineTmpl, err = parseEnvTemplate("CHUNK_COMBINE_TEMPLATE", defaultChunkCombineTemplate, funcs); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func parseEnvTemplate(name, def string, funcs template.FuncMap) (*template.Template, error) {
+	text := os.Getenv(name)
+	if text == "" {
+		text = def
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// split cuts text into chunks of about sizeTokens, each starting
+// overlapTokens before the end of the previous one. Cuts are moved back to
+// the nearest whitespace when there is some in the last tenth of a chunk,
+// and overlaps start at a word boundary where possible.
+func (c *chunker) split(text string) []string {
+	runes := []rune(text)
+	size, overlap := c.sizeTokens*4, c.overlapTokens*4
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			chunks = append(chunks, string(runes[start:]))
+			break
+		}
+		for i := end; i > end-size/10; i-- {
+			if unicode.IsSpace(runes[i]) {
+				end = i
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[start:end]))
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		for i := next; i < end; i++ {
+			if unicode.IsSpace(runes[i]) {
+				next = i + 1
+				break
+			}
+		}
+		start = next
+	}
+	return chunks
+}
+
+// validate rejects prompts that would split into more than maxChunks
+// chunks, each of which is a billed invocation.
+func (c *chunker) validate(prompt string) error {
+	if n := len(c.split(prompt)); n > c.maxChunks {
+		return fmt.Errorf("prompt would split into %d chunks, max %d", n, c.maxChunks)
+	}
+	return nil
+}
+
+// run invokes the model once per chunk of prompt and then combines the
+// results, returning the combined result and per-invocation usage. A prompt
+// that fits in one chunk is invoked as is, without a combine step. Callers
+// check the chunk count with validate first.
+func (c *chunker) run(ctx context.Context, svc invoker, modelID, prompt string) (string, []ChunkUsage, error) {
+	chunks := c.split(prompt)
+	if len(chunks) == 1 {
+		output, err := invokeText(ctx, svc, modelID, prompt)
+		if err != nil {
+			return "", nil, err
+		}
+		return output, []ChunkUsage{{Index: 1, InputTokens: estimateTokens(prompt), OutputTokens: estimateTokens(output)}}, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunkPrompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		var b strings.Builder
+		data := struct {
+			Index, Total int
+			Text         string
+		}{i + 1, len(chunks), chunk}
+		if err := c.mapTmpl.Execute(&b, data); err != nil {
+			return "", nil, fmt.Errorf("failed to format chunk prompt: %w", err)
+		}
+		chunkPrompts[i] = b.String()
+	}
+
+	results := make([]string, len(chunks))
+	usage := make([]ChunkUsage, len(chunks), len(chunks)+1)
+	var failOnce sync.Once
+	var failErr error
+	concurrency := c.concurrency
+	if c.limiter != nil {
+		if limit := c.limiter.limit(modelID); limit > 0 && limit < concurrency {
+			concurrency = limit
+		}
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, chunkPrompt := range chunkPrompts {
+		wg.Add(1)
+		go func(i int, chunkPrompt string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			output, err := invokeText(ctx, svc, modelID, chunkPrompt)
+			if err != nil {
+				failOnce.Do(func() {
+					failErr = fmt.Errorf("chunk %d: %w", i+1, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = output
+			usage[i] = ChunkUsage{Index: i + 1, InputTokens: estimateTokens(chunkPrompt), OutputTokens: estimateTokens(output)}
+		}(i, chunkPrompt)
+	}
+	wg.Wait()
+	if failErr != nil {
+		return "", nil, failErr
+	}
+
+	output, err := c.combine(ctx, svc, modelID, results, &usage)
+	if err != nil {
+		return "", nil, err
+	}
+	return output, usage, nil
+}
+
+// combine merges results with one invocation. When the combine prompt is
+// too long for the model, each half of the results is combined first and
+// the two summaries are then merged. Halves shrink on every step and a pair
+// of summaries that still does not fit is rejected, so the recursion ends.
+// Every combine invocation is appended to usage.
+func (c *chunker) combine(ctx context.Context, svc invoker, modelID string, results []string, usage *[]ChunkUsage) (string, error) {
+	if len(results) == 1 {
+		return results[0], nil
+	}
+	var b strings.Builder
+	if err := c.combineTmpl.Execute(&b, struct{ Results []string }{results}); err != nil {
+		return "", fmt.Errorf("failed to format combine prompt: %w", err)
+	}
+	combinePrompt := b.String()
+	if err := validatePromptLength(c.capabilities, modelID, combinePrompt); err != nil {
+		if len(results) == 2 {
+			return "", &requestError{http.StatusBadRequest, "prompt_too_long", fmt.Sprintf("chunk summaries cannot be combined: %v", err)}
+		}
+		mid := len(results) / 2
+		first, err := c.combine(ctx, svc, modelID, results[:mid], usage)
+		if err != nil {
+			return "", err
+		}
+		second, err := c.combine(ctx, svc, modelID, results[mid:], usage)
+		if err != nil {
+			return "", err
+		}
+		return c.combine(ctx, svc, modelID, []string{first, second}, usage)
+	}
+
+	output, err := invokeText(ctx, svc, modelID, combinePrompt)
+	if err != nil {
+		return "", fmt.Errorf("combine: %w", err)
+	}
+	*usage = append(*usage, ChunkUsage{Index: len(*usage) + 1, Combine: true, InputTokens: estimateTokens(combinePrompt), OutputTokens: estimateTokens(output)})
+	return output, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// testChunker splits into chunks of 40 runes with no overlap, and combines
+// results by plain concatenation so combine prompt sizes are predictable.
+func testChunker(t *testing.T, capabilities map[string]modelCapabilities) *chunker {
+	t.Helper()
+	t.Setenv("CHUNK_SIZE_TOKENS", "10")
+	t.Setenv("CHUNK_OVERLAP_TOKENS", "0")
+	t.Setenv("CHUNK_MAX_CHUNKS", "4")
+	t.Setenv("CHUNK_COMBINE_TEMPLATE", "{{range .Results}}{{.}}{{end}}")
+	c, err := loadChunker(capabilities, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestChunkerValidateRejectsTooManyChunks(t *testing.T) {
+	c := testChunker(t, nil)
+	if err := c.validate(strings.Repeat("x", 4*40)); err != nil {
+		t.Errorf("validate(4 chunks) = %v, want nil", err)
+	}
+	if err := c.validate(strings.Repeat("x", 4*40+1)); err == nil {
+		t.Error("validate(5 chunks) succeeded, want error")
+	}
+}
+
+func countCombines(usage []ChunkUsage) int {
+	var n int
+	for _, u := range usage {
+		if u.Combine {
+			n++
+		}
+	}
+	return n
+}
+
+func TestChunkerRunCombinesOnce(t *testing.T) {
+	c := testChunker(t, nil)
+	f := &fakeInvoker{output: "summary"}
+	output, usage, err := c.run(context.Background(), f, "model", strings.Repeat("x", 3*40))
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if output != "summary" {
+		t.Errorf("output = %q, want %q", output, "summary")
+	}
+	if f.calls != 4 || len(usage) != 4 || countCombines(usage) != 1 {
+		t.Errorf("calls = %d, usage = %+v; want 3 chunks and 1 combine", f.calls, usage)
+	}
+}
+
+func TestChunkerRunReducesRecursivelyWhenCombineIsTooLong(t *testing.T) {
+	// Each summary is 10 tokens: two fit the 20 token limit, four do not.
+	c := testChunker(t, map[string]modelCapabilities{"model": {MaxInputTokens: 20}})
+	f := &fakeInvoker{output: strings.Repeat("s", 40)}
+	_, usage, err := c.run(context.Background(), f, "model", strings.Repeat("x", 4*40))
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := countCombines(usage); got != 3 {
+		t.Errorf("combines = %d, want 3 (two halves and a final merge)", got)
+	}
+	for _, u := range usage {
+		if u.Combine && u.InputTokens > 20 {
+			t.Errorf("combine %d sent %d tokens, over the model limit", u.Index, u.InputTokens)
+		}
+	}
+}
+
+func TestChunkerRunFailsWhenOneSummaryIsTooLong(t *testing.T) {
+	c := testChunker(t, map[string]modelCapabilities{"model": {MaxInputTokens: 5}})
+	f := &fakeInvoker{output: strings.Repeat("s", 40)}
+	if _, _, err := c.run(context.Background(), f, "model", strings.Repeat("x", 2*40)); err == nil {
+		t.Error("run succeeded, want combine error")
+	}
+}
+
+func TestChunkerRunStopsWhenSummariesDoNotShrink(t *testing.T) {
+	// Each summary is 15 tokens, so no pair fits the 20 token limit however
+	// often the halves are summarized again.
+	c := testChunker(t, map[string]modelCapabilities{"model": {MaxInputTokens: 20}})
+	f := &fakeInvoker{output: strings.Repeat("s", 60)}
+	_, _, err := c.run(context.Background(), f, "model", strings.Repeat("x", 4*40))
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) || reqErr.Code != "prompt_too_long" {
+		t.Fatalf("err = %v, want a prompt_too_long requestError", err)
+	}
+	if f.calls != 4 {
+		t.Errorf("calls = %d, want 4 (the chunks only)", f.calls)
+	}
+}
+
+// slowInvoker answers every call with output after delay.
+type slowInvoker struct {
+	delay  time.Duration
+	output string
+}
+
+func (s slowInvoker) InvokeModelWithContext(ctx aws.Context, input *bedrock.InvokeModelInput, opts ...request.Option) (*bedrock.InvokeModelOutput, error) {
+	time.Sleep(s.delay)
+	return &bedrock.InvokeModelOutput{OutputText: aws.String(s.output)}, nil
+}
+
+func TestChunkerRunStaysWithinModelConcurrency(t *testing.T) {
+	// With a slot queue timeout far below the invoke time, a chunk waiting
+	// on another chunk of the same request fails with errModelBusy.
+	limiter := &modelLimiter{limits: map[string]int{"model": 1}, queueTimeout: time.Millisecond, sems: map[string]*modelSlots{}}
+	c := testChunker(t, nil)
+	c.limiter = limiter
+	svc := limitedInvoker{invoker: slowInvoker{delay: 20 * time.Millisecond, output: "summary"}, limiter: limiter}
+	if _, _, err := c.run(context.Background(), svc, "model", strings.Repeat("x", 3*40)); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// configValue holds one setting from a config file as the text its
+// environment variable would contain. Strings are kept as is; numbers,
+// booleans, objects and arrays keep their JSON form, so JSON-valued
+// settings like MODEL_ROUTES can be written inline.
+type configValue struct {
+	set  bool
+	text string
+}
+
+func (v *configValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = configValue{set: true, text: s}
+		return nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return err
+	}
+	*v = configValue{set: true, text: compact.String()}
+	return nil
+}
+
+// Config lists every setting that can come from a config file. The json tag
+// is the key in the file and the env tag the environment variable that
+// takes precedence over it. Settings tagged kind:"bool" must be true or
+// false.
+type Config struct {
+	Port                     configValue `json:"port" env:"PORT"`
+	AWSRegion                configValue `json:"aws_region" env:"AWS_REGION"`
+	AWSRegionPrimary         configValue `json:"aws_region_primary" env:"AWS_REGION_PRIMARY"`
+	AWSRegionSecondary       configValue `json:"aws_region_secondary" env:"AWS_REGION_SECONDARY"`
+	AWSBedrockEndpoint       configValue `json:"aws_bedrock_endpoint" env:"AWS_BEDROCK_ENDPOINT"`
+	LogLevel                 configValue `json:"log_level" env:"LOG_LEVEL"`
+	LogSampleRate            configValue `json:"log_sample_rate" env:"LOG_SAMPLE_RATE"`
+	AdminToken               configValue `json:"admin_token" env:"ADMIN_TOKEN"`
+	InferenceProfileMap      configValue `json:"inference_profile_map" env:"INFERENCE_PROFILE_MAP"`
+	ModelRoutes              configValue `json:"model_routes" env:"MODEL_ROUTES"`
+	ModelCapabilities        configValue `json:"model_capabilities" env:"MODEL_CAPABILITIES"`
+	AllowCIDRs               configValue `json:"allow_cidrs" env:"ALLOW_CIDRS"`
+	DenyCIDRs                configValue `json:"deny_cidrs" env:"DENY_CIDRS"`
+	TrustProxy               configValue `json:"trust_proxy" env:"TRUST_PROXY" kind:"bool"`
+	TrustedProxyCIDRs        configValue `json:"trusted_proxy_cidrs" env:"TRUSTED_PROXY_CIDRS"`
+	CORSAllowOrigins         configValue `json:"cors_allow_origins" env:"CORS_ALLOW_ORIGINS"`
+	CORSAllowCredentials     configValue `json:"cors_allow_credentials" env:"CORS_ALLOW_CREDENTIALS" kind:"bool"`
+	CORSExposeHeaders        configValue `json:"cors_expose_headers" env:"CORS_EXPOSE_HEADERS"`
+	ForwardHeaders           configValue `json:"forward_headers" env:"FORWARD_HEADERS"`
+	PromptTransformers       configValue `json:"prompt_transformers" env:"PROMPT_TRANSFORMERS"`
+	FewShotTemplate          configValue `json:"few_shot_template" env:"FEW_SHOT_TEMPLATE"`
+	MaxFewShotExamples       configValue `json:"max_few_shot_examples" env:"MAX_FEW_SHOT_EXAMPLES"`
+	ChunkSizeTokens          configValue `json:"chunk_size_tokens" env:"CHUNK_SIZE_TOKENS"`
+	ChunkOverlapTokens       configValue `json:"chunk_overlap_tokens" env:"CHUNK_OVERLAP_TOKENS"`
+	ChunkConcurrency         configValue `json:"chunk_concurrency" env:"CHUNK_CONCURRENCY"`
+	ChunkMaxChunks           configValue `json:"chunk_max_chunks" env:"CHUNK_MAX_CHUNKS"`
+	ChunkMapTemplate         configValue `json:"chunk_map_template" env:"CHUNK_MAP_TEMPLATE"`
+	ChunkCombineTemplate     configValue `json:"chunk_combine_template" env:"CHUNK_COMBINE_TEMPLATE"`
+	BedrockMaxRetries        configValue `json:"bedrock_max_retries" env:"BEDROCK_MAX_RETRIES"`
+	BedrockRetryBaseDelay    configValue `json:"bedrock_retry_base_delay" env:"BEDROCK_RETRY_BASE_DELAY"`
+	BedrockRetryMaxDelay     configValue `json:"bedrock_retry_max_delay" env:"BEDROCK_RETRY_MAX_DELAY"`
+	ModelConcurrency         configValue `json:"model_concurrency" env:"MODEL_CONCURRENCY"`
+	ModelConcurrencyDefault  configValue `json:"model_concurrency_default" env:"MODEL_CONCURRENCY_DEFAULT"`
+	ModelQueueTimeout        configValue `json:"model_queue_timeout" env:"MODEL_QUEUE_TIMEOUT"`
+	WarmupInterval           configValue `json:"warmup_interval" env:"WARMUP_INTERVAL"`
+	WarmupModel              configValue `json:"warmup_model" env:"WARMUP_MODEL"`
+	ShedLatencyThreshold     configValue `json:"shed_latency_threshold" env:"SHED_LATENCY_THRESHOLD"`
+	ShedFraction             configValue `json:"shed_fraction" env:"SHED_FRACTION"`
+	ShedWindow               configValue `json:"shed_window" env:"SHED_WINDOW"`
+	LivenessTimeout          configValue `json:"liveness_timeout" env:"LIVENESS_TIMEOUT"`
+	ReadinessCheckInterval   configValue `json:"readiness_check_interval" env:"READINESS_CHECK_INTERVAL"`
+	MaxRequestBytes          configValue `json:"max_request_bytes" env:"MAX_REQUEST_BYTES"`
+	ResponseTrailers         configValue `json:"response_trailers" env:"RESPONSE_TRAILERS" kind:"bool"`
+	CaptureDir               configValue `json:"capture_dir" env:"CAPTURE_DIR"`
+	CaptureMaxFiles          configValue `json:"capture_max_files" env:"CAPTURE_MAX_FILES"`
+	CaptureMaxMB             configValue `json:"capture_max_mb" env:"CAPTURE_MAX_MB"`
+	InjectionPatterns        configValue `json:"injection_patterns" env:"INJECTION_PATTERNS"`
+	ModerationModel          configValue `json:"moderation_model" env:"MODERATION_MODEL"`
+	ModerationThreshold      configValue `json:"moderation_threshold" env:"MODERATION_THRESHOLD"`
+	ModerationPatterns       configValue `json:"moderation_patterns" env:"MODERATION_PATTERNS"`
+	ModerationPlaceholder    configValue `json:"moderation_placeholder" env:"MODERATION_PLACEHOLDER"`
+	EnableUI                 configValue `json:"enable_ui" env:"ENABLE_UI" kind:"bool"`
+	MaintenanceMode          configValue `json:"maintenance_mode" env:"MAINTENANCE_MODE" kind:"bool"`
+	EnableInjectionDetection configValue `json:"enable_injection_detection" env:"ENABLE_INJECTION_DETECTION" kind:"bool"`
+	InjectionBlock           configValue `json:"injection_block" env:"INJECTION_BLOCK" kind:"bool"`
+	OutputModeration         configValue `json:"output_moderation" env:"OUTPUT_MODERATION" kind:"bool"`
+}
+
+// loadConfigFile reads a JSON file, or a flat YAML subset file (see
+// flatYAMLToJSON) when the extension is .yaml or .yml. Unknown keys are
+// rejected to catch typos.
+func loadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		if data, err = flatYAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	cfg := &Config{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// validate rejects boolean settings that are not true or false, such as
+// YAML 1.1 style yes/no, which the server would otherwise read as false.
+func (c *Config) validate() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		value := v.Field(i).Interface().(configValue)
+		if !value.set || t.Field(i).Tag.Get("kind") != "bool" {
+			continue
+		}
+		if value.text != "true" && value.text != "false" {
+			return fmt.Errorf("%s must be true or false, got %q", t.Field(i).Tag.Get("json"), value.text)
+		}
+	}
+	return nil
+}
+
+// envValues maps the environment variable of every setting in the file to
+// its value.
+func (c *Config) envValues() map[string]string {
+	values := map[string]string{}
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if value := v.Field(i).Interface().(configValue); value.set {
+			values[t.Field(i).Tag.Get("env")] = value.text
+		}
+	}
+	return values
+}
+
+// applyToEnv exports every setting from the file whose environment variable
+// is not already set, so environment variables take precedence and the rest
+// of the server keeps reading settings from the environment.
+func (c *Config) applyToEnv() error {
+	for name, text := range c.envValues() {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		if err := os.Setenv(name, text); err != nil {
+			return fmt.Errorf("failed to set %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// flatYAMLToJSON converts a flat config file into a JSON object. The format
+// is a subset of YAML, not YAML: only top-level "key: value" lines, with
+// plain, single-quoted or double-quoted keys and values, # comments and
+// structured values such as model_routes written as inline JSON. Double
+// quoted strings use JSON escapes. Nested block mappings and sequences are
+// rejected.
+func flatYAMLToJSON(data []byte) ([]byte, error) {
+	obj := map[string]json.RawMessage{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(trimmed, "- ") {
+			return nil, fmt.Errorf("line %d: nested values are not supported in flat config files, use inline JSON for structured values", n)
+		}
+		key, value, err := cutFlatKey(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		raw, err := yamlScalarToJSON(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		obj[key] = raw
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+// cutFlatKey splits a "key: value" line into its key, unquoted if quoted,
+// and its trimmed value.
+func cutFlatKey(line string) (string, string, error) {
+	if strings.HasPrefix(line, `"`) || strings.HasPrefix(line, "'") {
+		key, rest, err := cutQuoted(line)
+		if err != nil {
+			return "", "", err
+		}
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", fmt.Errorf("expected \"key: value\"")
+		}
+		return key, strings.TrimSpace(rest[1:]), nil
+	}
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", fmt.Errorf("expected \"key: value\"")
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), nil
+}
+
+// cutQuoted reads the single- or double-quoted string s starts with,
+// returning it unquoted along with the text after the closing quote.
+func cutQuoted(s string) (string, string, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			if quote == '\'' {
+				return strings.ReplaceAll(s[1:i], "''", "'"), s[i+1:], nil
+			}
+			var unquoted string
+			if err := json.Unmarshal([]byte(s[:i+1]), &unquoted); err != nil {
+				return "", "", fmt.Errorf("invalid quoted string %s", s[:i+1])
+			}
+			return unquoted, s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted string %s", s)
+}
+
+// yamlScalarToJSON converts one value: quoted or plain strings, numbers,
+// booleans, and inline JSON objects or arrays.
+func yamlScalarToJSON(value string) (json.RawMessage, error) {
+	switch {
+	case value == "", value == "~", value == "null":
+		return json.RawMessage(`""`), nil
+	case strings.HasPrefix(value, `"`), strings.HasPrefix(value, "'"):
+		s, rest, err := cutQuoted(value)
+		if err != nil {
+			return nil, err
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("unexpected %q after quoted string", rest)
+		}
+		return json.Marshal(s)
+	case strings.HasPrefix(value, "{"), strings.HasPrefix(value, "["):
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("invalid inline JSON %s", value)
+		}
+		return json.RawMessage(value), nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	switch value {
+	case "true", "false":
+		return json.RawMessage(value), nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return json.RawMessage(value), nil
+	}
+	return json.Marshal(value)
+}
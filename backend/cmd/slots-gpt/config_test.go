@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFileYAML(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `# server settings
+port: 9090
+enable_ui: true
+cors_allow_origins: 'https://a.example, https://b.example'
+model_routes: {"chat": [{"model": "A", "weight": 70}, {"model": "B", "weight": 30}]}
+shed_fraction: 0.25 # of requests
+`)
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+	for name, want := range map[string]string{
+		"port":               "9090",
+		"enable_ui":          "true",
+		"cors_allow_origins": "https://a.example, https://b.example",
+		"model_routes":       `{"chat":[{"model":"A","weight":70},{"model":"B","weight":30}]}`,
+		"shed_fraction":      "0.25",
+	} {
+		if got := configText(cfg, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLoadConfigFileRejectsNonBooleanFlags(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "enable_ui: yes\n",
+		"config.yml":  "trust_proxy: on\n",
+		"config.json": `{"maintenance_mode": "1"}`,
+	} {
+		_, err := loadConfigFile(writeConfigFile(t, name, content))
+		if err == nil || !strings.Contains(err.Error(), "must be true or false") {
+			t.Errorf("loadConfigFile(%s %q) error = %v, want a boolean error", name, content, err)
+		}
+	}
+}
+
+func TestLoadConfigFileYAMLQuoting(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `"port": "8080" # quoted
+'log_level': 'it''s debug'
+moderation_placeholder: "tab\there \u00e9"
+`)
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+	for name, want := range map[string]string{
+		"port":                   "8080",
+		"log_level":              "it's debug",
+		"moderation_placeholder": "tab\there \u00e9",
+	} {
+		if got := configText(cfg, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLoadConfigFileRejectsNestedYAML(t *testing.T) {
+	_, err := loadConfigFile(writeConfigFile(t, "config.yaml", "model_routes:\n  chat:\n    - model: A\n"))
+	if err == nil {
+		t.Error("loadConfigFile succeeded, want an error for nested YAML")
+	}
+}
+
+func TestLoadConfigFileRejectsUnknownKeys(t *testing.T) {
+	if _, err := loadConfigFile(writeConfigFile(t, "config.json", `{"prot": "8080"}`)); err == nil {
+		t.Error("loadConfigFile succeeded, want an error for an unknown key")
+	}
+}
+
+// configText returns the text of the setting with the given json key.
+func configText(cfg *Config, key string) string {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("json") == key {
+			return v.Field(i).Interface().(configValue).text
+		}
+	}
+	return ""
+}
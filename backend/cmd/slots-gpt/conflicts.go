@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Precedence rules for PromptRequest fields that interact:
+//   - model is looked up in MODEL_ROUTES first; the chosen backing model is
+//     then looked up in INFERENCE_PROFILE_MAP, and anything else is sent to
+//     Bedrock as is.
+//   - examples and JSON mode instructions are applied by the prompt
+//     transformers in PROMPT_TRANSFORMERS order; by default examples are
+//     rendered first and JSON mode instructions appended after them.
+//   - response_schema is only used in JSON mode. Sending it without json_mode
+//     is treated as a conflict rather than silently ignoring the schema.
+//   - chunk splits the raw prompt and replaces it with the chunk and combine
+//     templates, so it cannot be combined with examples or json_mode.
+
+// fieldConflict names request fields that cannot be combined and why.
+type fieldConflict struct {
+	Fields []string
+	Reason string
+}
+
+func (c fieldConflict) String() string {
+	return fmt.Sprintf("%s (%s)", strings.Join(c.Fields, ", "), c.Reason)
+}
+
+// findConflicts returns every ambiguous field combination in req.
+func findConflicts(req PromptRequest) []fieldConflict {
+	var conflicts []fieldConflict
+	if len(req.ResponseSchema) > 0 && !req.JSONMode {
+		conflicts = append(conflicts, fieldConflict{
+			Fields: []string{"response_schema", "json_mode"},
+			Reason: "response_schema is only applied when json_mode is true",
+		})
+	}
+	if req.Chunk && len(req.Examples) > 0 {
+		conflicts = append(conflicts, fieldConflict{
+			Fields: []string{"chunk", "examples"},
+			Reason: "chunked prompts are formatted with the chunk templates, not few-shot examples",
+		})
+	}
+	if req.Chunk && req.JSONMode {
+		conflicts = append(conflicts, fieldConflict{
+			Fields: []string{"chunk", "json_mode"},
+			Reason: "the combined output of chunked prompts is not validated as JSON",
+		})
+	}
+	return conflicts
+}
+
+// conflictError describes conflicts as a client-facing error message.
+func conflictError(conflicts []fieldConflict) string {
+	parts := make([]string, len(conflicts))
+	for i, c := range conflicts {
+		parts[i] = c.String()
+	}
+	return "Conflicting request fields: " + strings.Join(parts, "; ")
+}
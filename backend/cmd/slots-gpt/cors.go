@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// corsConfig controls the CORS headers sent to browser clients. CORS is
+// disabled when no origins are allowed.
+type corsConfig struct {
+	AllowOrigins     []string
+	AllowCredentials bool
+	ExposeHeaders    []string
+}
+
+// loadCORSConfig reads CORS_ALLOW_ORIGINS, CORS_ALLOW_CREDENTIALS and
+// CORS_EXPOSE_HEADERS. A wildcard origin cannot be combined with
+// credentials: it would let any website make credentialed calls, so
+// credentialed CORS needs explicit origins.
+func loadCORSConfig() (corsConfig, error) {
+	c := corsConfig{
+		AllowOrigins:     envList("CORS_ALLOW_ORIGINS"),
+		AllowCredentials: envBool("CORS_ALLOW_CREDENTIALS"),
+		ExposeHeaders:    envList("CORS_EXPOSE_HEADERS"),
+	}
+	if c.AllowCredentials && slices.Contains(c.AllowOrigins, "*") {
+		return corsConfig{}, fmt.Errorf("CORS_ALLOW_ORIGINS=* cannot be combined with CORS_ALLOW_CREDENTIALS=true; list the allowed origins explicitly")
+	}
+	return c, nil
+}
+
+// allowOrigin returns the value for Access-Control-Allow-Origin, or "" when
+// the origin is not allowed. Listed origins are echoed back, which
+// credentialed requests require.
+func (c corsConfig) allowOrigin(origin string) string {
+	for _, allowed := range c.AllowOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if allowed == origin {
+			return origin
+		}
+	}
+	return ""
+}
+
+// corsMiddleware adds CORS headers for allowed origins and answers preflight
+// requests without passing them to next.
+func corsMiddleware(c corsConfig, next http.Handler) http.Handler {
+	if len(c.AllowOrigins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := c.allowOrigin(origin)
+		if allowed == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", allowed)
+		if c.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if len(c.ExposeHeaders) > 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
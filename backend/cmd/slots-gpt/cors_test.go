@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadCORSConfigRejectsWildcardWithCredentials(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://app.example, *")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	if _, err := loadCORSConfig(); err == nil {
+		t.Error("loadCORSConfig succeeded, want error for * with credentials")
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name            string
+		origins         string
+		credentials     string
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{"wildcard", "*", "", "https://any.example", "*", ""},
+		{"listed origin", "https://app.example", "", "https://app.example", "https://app.example", ""},
+		{"unlisted origin", "https://app.example", "", "https://evil.example", "", ""},
+		{"credentials with listed origin", "https://app.example", "true", "https://app.example", "https://app.example", "true"},
+		{"credentials with unlisted origin", "https://app.example", "true", "https://evil.example", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOW_ORIGINS", tt.origins)
+			t.Setenv("CORS_ALLOW_CREDENTIALS", tt.credentials)
+			c, err := loadCORSConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler := corsMiddleware(c, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodPost, "/api/send-prompt", nil)
+			r.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
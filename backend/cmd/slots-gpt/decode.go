@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// decodeError turns a json.Decoder error into a message telling the client
+// what is wrong with the request body.
+func decodeError(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var sizeErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &sizeErr):
+		return fmt.Sprintf("Request body must not exceed %d bytes", sizeErr.Limit)
+	case errors.Is(err, io.EOF):
+		return "Request body must not be empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "Request body contains malformed JSON: unexpected end of input"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("Request body contains malformed JSON at byte offset %d: %v", syntaxErr.Offset, syntaxErr)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Sprintf("Request body must be a JSON %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value)
+		}
+		return fmt.Sprintf("Request field %q must be a JSON %s, got %s", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Value)
+	default:
+		return fmt.Sprintf("Invalid request payload: %v", err)
+	}
+}
+
+// jsonTypeName names the JSON type a Go type is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	default:
+		return t.String()
+	}
+}
+
+// limitBody caps request bodies read by next, and by any middleware inside
+// it, at maxBytes. Reads past the cap fail with *http.MaxBytesError.
+func limitBody(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ErrorResponse is the body of every error from the /api/ endpoints.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError identifies what is wrong with a request by code, so clients can
+// branch on it instead of parsing the message. AWSRequestID is set for
+// failed Bedrock calls, for escalating to AWS support, and ValidationErrors
+// for JSON mode output that did not match the response schema.
+type APIError struct {
+	Code             string   `json:"code"`
+	Message          string   `json:"message"`
+	AWSRequestID     string   `json:"aws_request_id,omitempty"`
+	ValidationErrors []string `json:"validation_errors,omitempty"`
+}
+
+// writeAPIError answers status with an ErrorResponse body.
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeError(w, status, APIError{Code: code, Message: message})
+}
+
+// writeError answers status with apiErr as an ErrorResponse body.
+func writeError(w http.ResponseWriter, status int, apiErr APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: apiErr})
+}
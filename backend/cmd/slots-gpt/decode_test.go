@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteDecodeErrorShape(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		body       string
+		limit      int64
+		wantStatus int
+		wantCode   string
+	}{
+		{"empty body", "", "", 1 << 20, http.StatusBadRequest, "invalid_request"},
+		{"malformed JSON", "", `{"prompt":`, 1 << 20, http.StatusBadRequest, "invalid_request"},
+		{"wrong field type", "", `{"prompt":1}`, 1 << 20, http.StatusBadRequest, "invalid_request"},
+		{"unsupported version", "9", `{"prompt":"hi"}`, 1 << 20, http.StatusBadRequest, "unsupported_version"},
+		{"too large", "", `{"prompt":"` + strings.Repeat("x", 100) + `"}`, 10, http.StatusRequestEntityTooLarge, "request_too_large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			body := http.MaxBytesReader(w, io.NopCloser(strings.NewReader(tt.body)), tt.limit)
+			_, _, err := decodePromptRequest(tt.header, body)
+			if err == nil {
+				t.Fatal("decodePromptRequest succeeded, want error")
+			}
+			writeDecodeError(w, err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("body %q is not an ErrorResponse: %v", w.Body.String(), err)
+			}
+			if resp.Error.Code != tt.wantCode || resp.Error.Message == "" {
+				t.Errorf("error = %+v, want code %q with a message", resp.Error, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envBool reports whether the named variable is set to a true value as
+// understood by strconv.ParseBool. Unset or unparsable values are false;
+// unparsable ones are logged.
+func envBool(name string) bool {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return false
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q, using false", name, raw)
+		return false
+	}
+	return v
+}
+
+// environNames returns the names of the variables currently set in the
+// process environment.
+func environNames() map[string]bool {
+	names := map[string]bool{}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		names[name] = true
+	}
+	return names
+}
+
+// envList splits a comma-separated variable into its trimmed, non-empty items.
+func envList(name string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envInt returns the named variable as an int, or def when it is unset or
+// not a valid integer.
+func envInt(name string, def int) int {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q, using %d", name, raw, def)
+		return def
+	}
+	return v
+}
+
+// envDuration returns the named variable parsed by time.ParseDuration, or def
+// when it is unset or invalid.
+func envDuration(name string, def time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q, using %s", name, raw, def)
+		return def
+	}
+	return v
+}
+
+// envFloat returns the named variable as a float64, or def when it is unset
+// or not a valid number.
+func envFloat(name string, def float64) float64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q, using %v", name, raw, def)
+		return def
+	}
+	return v
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// defaultFewShotTemplate lays examples out as Input/Output pairs followed by
+// the actual prompt, leaving the final Output for the model to complete.
+const defaultFewShotTemplate = `{{range .Examples}}Input: {{.Input}}
+Output: {{.Output}}
+
+{{end}}Input: {{.Prompt}}
+Output:`
+
+// FewShotExample is one input/output demonstration included ahead of the prompt.
+type FewShotExample struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// fewShotFormatter renders a prompt and its examples into a single few-shot
+// prompt for models without native support for examples.
+type fewShotFormatter struct {
+	tmpl        *template.Template
+	maxExamples int
+}
+
+// loadFewShotFormatter builds the formatter from FEW_SHOT_TEMPLATE, a
+// text/template over .Examples and .Prompt, and MAX_FEW_SHOT_EXAMPLES.
+func loadFewShotFormatter() (*fewShotFormatter, error) {
+	text := os.Getenv("FEW_SHOT_TEMPLATE")
+	if text == "" {
+		text = defaultFewShotTemplate
+	}
+	tmpl, err := template.New("few-shot").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEW_SHOT_TEMPLATE: %w", err)
+	}
+	return &fewShotFormatter{
+		tmpl:        tmpl,
+		maxExamples: envInt("MAX_FEW_SHOT_EXAMPLES", 8),
+	}, nil
+}
+
+// validate checks the example count against the configured maximum and that
+// every example is complete.
+func (f *fewShotFormatter) validate(examples []FewShotExample) error {
+	if len(examples) > f.maxExamples {
+		return fmt.Errorf("too many examples: got %d, max %d", len(examples), f.maxExamples)
+	}
+	for i, ex := range examples {
+		if ex.Input == "" || ex.Output == "" {
+			return fmt.Errorf("example %d: input and output are required", i)
+		}
+	}
+	return nil
+}
+
+// format returns prompt unchanged when there are no examples.
+func (f *fewShotFormatter) format(prompt string, examples []FewShotExample) (string, error) {
+	if len(examples) == 0 {
+		return prompt, nil
+	}
+	var b strings.Builder
+	data := struct {
+		Examples []FewShotExample
+		Prompt   string
+	}{examples, prompt}
+	if err := f.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to format few-shot prompt: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/joho/godotenv"
+)
+
+// featureFlags holds boolean toggles read from the environment at startup
+// and re-read on SIGHUP, so features can be switched without a restart.
+type featureFlags struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// newFeatureFlags registers the named flags and loads their current values.
+func newFeatureFlags(names ...string) *featureFlags {
+	f := &featureFlags{flags: make(map[string]bool, len(names))}
+	for _, name := range names {
+		f.flags[name] = false
+	}
+	f.load()
+	return f
+}
+
+// Enabled reports whether the named flag is on. Unregistered flags are off.
+func (f *featureFlags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[name]
+}
+
+func (f *featureFlags) load() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name := range f.flags {
+		f.flags[name] = envBool(name)
+	}
+}
+
+func (f *featureFlags) String() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	pairs := make([]string, 0, len(f.flags))
+	for name, on := range f.flags {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", name, on))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// reloadOnSIGHUP reloads the flags from the .env file, and from the config
+// file at configPath when it is set, each time the process gets SIGHUP.
+// processEnv names the variables set before either was first loaded; like
+// startup, the reload leaves them alone and prefers .env over the file.
+func (f *featureFlags) reloadOnSIGHUP(processEnv map[string]bool, configPath string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			if err := f.reload(processEnv, configPath); err != nil {
+				log.Printf("Failed to reload feature flags, keeping %s: %v", f, err)
+				continue
+			}
+			log.Printf("Reloaded feature flags: %s", f)
+		}
+	}()
+}
+
+// reload re-reads .env and the config file and reloads the flags. Flags set
+// in processEnv keep their value, and flags removed from both files are
+// unset.
+func (f *featureFlags) reload(processEnv map[string]bool, configPath string) error {
+	dotenv, err := godotenv.Read()
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .env file: %w", err)
+	}
+	var file map[string]string
+	if configPath != "" {
+		cfg, err := loadConfigFile(configPath)
+		if err != nil {
+			return err
+		}
+		file = cfg.envValues()
+	}
+	for name := range f.flags {
+		if processEnv[name] {
+			continue
+		}
+		if v, ok := dotenv[name]; ok {
+			os.Setenv(name, v)
+		} else if v, ok := file[name]; ok {
+			os.Setenv(name, v)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+	f.load()
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFeatureFlagsReloadKeepsProcessEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/.env", []byte("MAINTENANCE_MODE=false\nENABLE_UI=true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Setenv("MAINTENANCE_MODE", "true")
+	t.Setenv("ENABLE_UI", "")
+	t.Setenv("OUTPUT_MODERATION", "true")
+	f := newFeatureFlags("MAINTENANCE_MODE", "ENABLE_UI", "OUTPUT_MODERATION")
+
+	// OUTPUT_MODERATION came from an earlier .env that no longer sets it.
+	if err := f.reload(map[string]bool{"MAINTENANCE_MODE": true}, ""); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	for name, want := range map[string]bool{"MAINTENANCE_MODE": true, "ENABLE_UI": true, "OUTPUT_MODERATION": false} {
+		if got := f.Enabled(name); got != want {
+			t.Errorf("%s = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestFeatureFlagsReloadReadsConfigFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	path := writeConfigFile(t, "config.json", `{"maintenance_mode": false}`)
+	t.Setenv("MAINTENANCE_MODE", "false")
+	f := newFeatureFlags("MAINTENANCE_MODE")
+
+	if err := os.WriteFile(path, []byte(`{"maintenance_mode": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.reload(nil, path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !f.Enabled("MAINTENANCE_MODE") {
+		t.Error("MAINTENANCE_MODE = false after editing the config file, want true")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"maintenance_mode": "yes"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.reload(nil, path); err == nil {
+		t.Error("reload of an invalid config file succeeded, want error")
+	}
+	if !f.Enabled("MAINTENANCE_MODE") {
+		t.Error("invalid config file changed MAINTENANCE_MODE, want it kept")
+	}
+}
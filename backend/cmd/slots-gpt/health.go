@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+type HealthResponse struct {
+	Status       string   `json:"status"`
+	BedrockP99Ms *float64 `json:"bedrock_p99_ms,omitempty"`
+	ShedRate     *float64 `json:"shed_rate,omitempty"`
+
+	RegionFailovers *int64         `json:"region_failovers,omitempty"`
+	ModelsInFlight  map[string]int `json:"models_in_flight,omitempty"`
+
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func writeHealth(w http.ResponseWriter, code int, health HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(health)
+}
+
+// heartbeat is ticked by a background goroutine. A stale heartbeat means the
+// Go scheduler is starved or the process is wedged.
+type heartbeat struct {
+	last    atomic.Int64
+	timeout time.Duration
+}
+
+func startHeartbeat(interval, timeout time.Duration) *heartbeat {
+	h := &heartbeat{timeout: timeout}
+	h.last.Store(time.Now().UnixNano())
+	go func() {
+		for range time.Tick(interval) {
+			h.last.Store(time.Now().UnixNano())
+		}
+	}()
+	return h
+}
+
+func (h *heartbeat) age() time.Duration {
+	return time.Since(time.Unix(0, h.last.Load()))
+}
+
+// healthzHandler is the liveness check: it fails only when the process
+// itself is stuck, which should make the orchestrator restart it. It does
+// not look at dependencies and keeps answering 200 in maintenance mode, so
+// an outage elsewhere never causes a restart loop. It also reports the load
+// shedder's view of Bedrock latency, the region failover count and the
+// in-flight invocations of concurrency-limited models.
+func healthzHandler(beat *heartbeat, shedder *loadShedder, failover *failoverInvoker, limiter *modelLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if age := beat.age(); age > beat.timeout {
+			writeHealth(w, http.StatusServiceUnavailable, HealthResponse{Status: fmt.Sprintf("heartbeat stale for %s", age.Round(time.Millisecond))})
+			return
+		}
+		p99 := float64(shedder.p99()) / float64(time.Millisecond)
+		rate := shedder.shedRate()
+		failovers := failover.Failovers()
+		writeHealth(w, http.StatusOK, HealthResponse{
+			Status:          "ok",
+			BedrockP99Ms:    &p99,
+			ShedRate:        &rate,
+			RegionFailovers: &failovers,
+			ModelsInFlight:  limiter.InFlight(),
+		})
+	}
+}
+
+// modelLister is the subset of the Bedrock control-plane client the
+// readiness probe uses.
+type modelLister interface {
+	ListFoundationModelsWithContext(ctx aws.Context, input *bedrock.ListFoundationModelsInput, opts ...request.Option) (*bedrock.ListFoundationModelsOutput, error)
+}
+
+// bedrockProbe checks that Bedrock is reachable with the configured
+// credentials, caching the result so readiness polls don't hit AWS each time.
+type bedrockProbe struct {
+	svc      modelLister
+	interval time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+// check returns the cached probe result, probing again once it is older
+// than interval. The probe runs detached from the readiness request with its
+// own timeout: an orchestrator giving up on /readyz must not cancel the call
+// and leave a "context canceled" error cached for the whole interval.
+func (p *bedrockProbe) check() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.checked) < p.interval {
+		return p.err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, p.err = p.svc.ListFoundationModelsWithContext(ctx, &bedrock.ListFoundationModelsInput{
+		ByOutputModality: aws.String(bedrock.ModelModalityText),
+	})
+	p.checked = time.Now()
+	return p.err
+}
+
+// readyzHandler is the readiness check: it fails when this instance cannot
+// usefully serve traffic, which should only take it out of the load
+// balancer. It fails in maintenance mode, when Bedrock is unreachable, and
+// when the load shedder is rejecting every request.
+func readyzHandler(flags *featureFlags, probe *bedrockProbe, shedder *loadShedder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if flags.Enabled("MAINTENANCE_MODE") {
+			writeHealth(w, http.StatusServiceUnavailable, HealthResponse{Status: "maintenance"})
+			return
+		}
+
+		ready := true
+		checks := map[string]string{"bedrock": "ok", "load_shedding": "ok"}
+		if err := probe.check(); err != nil {
+			ready = false
+			checks["bedrock"] = fmt.Sprintf("unreachable: %v", err)
+		}
+		if rate := shedder.shedRate(); rate >= 1 {
+			ready = false
+			checks["load_shedding"] = "saturated"
+		} else if rate > 0 {
+			checks["load_shedding"] = fmt.Sprintf("shedding %.0f%%", rate*100)
+		}
+
+		if !ready {
+			writeHealth(w, http.StatusServiceUnavailable, HealthResponse{Status: "not ready", Checks: checks})
+			return
+		}
+		writeHealth(w, http.StatusOK, HealthResponse{Status: "ready", Checks: checks})
+	}
+}
+
+// maintenanceMiddleware answers every /api/ request with 503 while
+// MAINTENANCE_MODE is on.
+func maintenanceMiddleware(flags *featureFlags, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if flags.Enabled("MAINTENANCE_MODE") && strings.HasPrefix(r.URL.Path, "/api/") {
+			writeAPIError(w, http.StatusServiceUnavailable, "maintenance", "The service is in maintenance mode, try again later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
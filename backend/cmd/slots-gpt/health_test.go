@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// fakeLister fails with err, or with the context's error when the probe
+// runs on a cancelled context.
+type fakeLister struct {
+	err   error
+	calls int
+}
+
+func (f *fakeLister) ListFoundationModelsWithContext(ctx aws.Context, input *bedrock.ListFoundationModelsInput, opts ...request.Option) (*bedrock.ListFoundationModelsOutput, error) {
+	f.calls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &bedrock.ListFoundationModelsOutput{}, f.err
+}
+
+func readyzStatus(t *testing.T, probe *bedrockProbe, ctx context.Context) int {
+	t.Helper()
+	handler := readyzHandler(newFeatureFlags("MAINTENANCE_MODE"), probe, &loadShedder{})
+	r := httptest.NewRequest(http.MethodGet, "/readyz", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestReadyzIgnoresCallerCancellation(t *testing.T) {
+	lister := &fakeLister{}
+	probe := &bedrockProbe{svc: lister, interval: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := readyzStatus(t, probe, ctx); got != http.StatusOK {
+		t.Errorf("status with cancelled request = %d, want %d", got, http.StatusOK)
+	}
+	if got := readyzStatus(t, probe, context.Background()); got != http.StatusOK {
+		t.Errorf("status after cancelled request = %d, want %d", got, http.StatusOK)
+	}
+	if lister.calls != 1 {
+		t.Errorf("probe calls = %d, want 1 (cached)", lister.calls)
+	}
+}
+
+func TestReadyzReportsBedrockFailure(t *testing.T) {
+	probe := &bedrockProbe{svc: &fakeLister{err: errors.New("no credentials")}, interval: time.Minute}
+	if got := readyzStatus(t, probe, context.Background()); got != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// defaultInjectionPatterns catch the most common prompt-injection phrasings.
+// They are a best-effort signal, not a security boundary.
+var defaultInjectionPatterns = []string{
+	`(?i)\b(ignore|disregard|forget)\b.{0,20}\b(previous|prior|above|earlier|all)\b.{0,20}\b(instructions|prompts?|rules|directions)\b`,
+	`(?i)\byou are now\b.{0,40}\b(unrestricted|jailbroken|dan|developer mode|no longer)\b`,
+	`(?i)\b(reveal|show|print|repeat)\b.{0,20}\b(your|the)\b.{0,20}\b(system prompt|hidden instructions|initial instructions)\b`,
+	`(?i)\b(jailbreak|god) mode\b`,
+	`(?im)^\s*(system|assistant)\s*:`,
+}
+
+// injectionDetector flags prompts matching any of its patterns.
+type injectionDetector struct {
+	patterns []*regexp.Regexp
+}
+
+// loadInjectionDetector compiles INJECTION_PATTERNS, a JSON array of regular
+// expressions, or the default patterns when it is unset.
+func loadInjectionDetector() (*injectionDetector, error) {
+	sources := defaultInjectionPatterns
+	if raw := os.Getenv("INJECTION_PATTERNS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &sources); err != nil {
+			return nil, fmt.Errorf("invalid INJECTION_PATTERNS: %w", err)
+		}
+	}
+	d := &injectionDetector{}
+	for _, src := range sources {
+		re, err := regexp.Compile(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid INJECTION_PATTERNS entry %q: %w", src, err)
+		}
+		d.patterns = append(d.patterns, re)
+	}
+	return d, nil
+}
+
+// detect returns the first pattern matched by any of the texts, or "".
+func (d *injectionDetector) detect(texts ...string) string {
+	for _, text := range texts {
+		for _, re := range d.patterns {
+			if re.MatchString(text) {
+				return re.String()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// invokeInfo collects details about how a request's model invocations were
+// served, for the handler to report back to the client. A request may invoke
+// models concurrently, so access goes through the methods.
+type invokeInfo struct {
+	mu          sync.Mutex
+	region      string
+	bedrockTime time.Duration
+	queueTime   time.Duration
+	header      http.Header
+}
+
+// setHeader records a response header to send with the answer.
+func (i *invokeInfo) setHeader(name, value string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.header == nil {
+		i.header = http.Header{}
+	}
+	i.header.Set(name, value)
+}
+
+// writeHeaders copies the recorded response headers to w.
+func (i *invokeInfo) writeHeaders(w http.ResponseWriter) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for name, values := range i.header {
+		w.Header()[name] = values
+	}
+}
+
+func (i *invokeInfo) setRegion(region string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.region = region
+}
+
+// Region returns the region that served the latest invocation.
+func (i *invokeInfo) Region() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.region
+}
+
+func (i *invokeInfo) addBedrockTime(d time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.bedrockTime += d
+}
+
+// BedrockTime returns the total time spent waiting on Bedrock calls,
+// excluding retry backoff.
+func (i *invokeInfo) BedrockTime() time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.bedrockTime
+}
+
+func (i *invokeInfo) addQueueTime(d time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.queueTime += d
+}
+
+// QueueTime returns the total time spent waiting for model concurrency slots.
+func (i *invokeInfo) QueueTime() time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.queueTime
+}
+
+type invokeInfoKey struct{}
+
+func withInvokeInfo(ctx context.Context) (context.Context, *invokeInfo) {
+	info := &invokeInfo{}
+	return context.WithValue(ctx, invokeInfoKey{}, info), info
+}
+
+// invokeInfoFrom returns the request's invokeInfo, or nil outside a request.
+func invokeInfoFrom(ctx context.Context) *invokeInfo {
+	info, _ := ctx.Value(invokeInfoKey{}).(*invokeInfo)
+	return info
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ipFilter restricts access by client IP. Deny rules take precedence over
+// allow rules, and an empty allow list allows everyone not denied.
+type ipFilter struct {
+	allow      []*net.IPNet
+	deny       []*net.IPNet
+	trustProxy bool
+	proxies    []*net.IPNet
+}
+
+// loadIPFilter reads ALLOW_CIDRS, DENY_CIDRS and TRUSTED_PROXY_CIDRS,
+// comma-separated lists of CIDR ranges or single IPs, and TRUST_PROXY.
+func loadIPFilter() (*ipFilter, error) {
+	allow, err := parseCIDRs("ALLOW_CIDRS", envList("ALLOW_CIDRS"))
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseCIDRs("DENY_CIDRS", envList("DENY_CIDRS"))
+	if err != nil {
+		return nil, err
+	}
+	proxies, err := parseCIDRs("TRUSTED_PROXY_CIDRS", envList("TRUSTED_PROXY_CIDRS"))
+	if err != nil {
+		return nil, err
+	}
+	return &ipFilter{allow: allow, deny: deny, trustProxy: envBool("TRUST_PROXY"), proxies: proxies}, nil
+}
+
+func parseCIDRs(name string, items []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, item := range items {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid %s entry %q", name, item)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: %w", name, item, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// clientIP returns the IP the request came from. Behind a trusted proxy it
+// walks X-Forwarded-For from the right, skipping the hops in
+// TRUSTED_PROXY_CIDRS, and returns the first address no trusted proxy added.
+// Entries to the left of it were supplied by the client and are ignored. An
+// unparsable entry in the trusted part of the chain yields nil, which the
+// filter rejects.
+func (f *ipFilter) clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote := net.ParseIP(host)
+	if !f.trustProxy {
+		return remote
+	}
+
+	var hops []string
+	for _, xff := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(xff, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			return nil
+		}
+		if i == 0 || !containsIP(f.proxies, ip) {
+			return ip
+		}
+	}
+	return remote
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *ipFilter) allowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if containsIP(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || containsIP(f.allow, ip)
+}
+
+// middleware rejects requests from disallowed client IPs with 403, with an
+// ErrorResponse body under /api/.
+func (f *ipFilter) middleware(next http.Handler) http.Handler {
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := f.clientIP(r); !f.allowed(ip) {
+			logf(r.Context(), "Rejected request from %s", ip)
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				writeAPIError(w, http.StatusForbidden, "forbidden", "Requests from this address are not allowed")
+				return
+			}
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
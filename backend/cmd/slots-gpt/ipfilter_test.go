@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mustCIDRs(t *testing.T, items ...string) []*net.IPNet {
+	t.Helper()
+	nets, err := parseCIDRs("TEST", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nets
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		allow      []string
+		deny       []string
+		trustProxy bool
+		proxies    []string
+		remoteAddr string
+		xff        []string
+		want       int
+	}{
+		{name: "ipv4 allowed", allow: []string{"10.0.0.0/8"}, remoteAddr: "10.1.2.3:1234", want: http.StatusOK},
+		{name: "ipv4 not allowed", allow: []string{"10.0.0.0/8"}, remoteAddr: "192.0.2.1:1234", want: http.StatusForbidden},
+		{name: "ipv4 single address", allow: []string{"192.0.2.1"}, remoteAddr: "192.0.2.1:1234", want: http.StatusOK},
+		{name: "deny wins over allow", allow: []string{"10.0.0.0/8"}, deny: []string{"10.1.0.0/16"}, remoteAddr: "10.1.2.3:1234", want: http.StatusForbidden},
+		{name: "deny only", deny: []string{"192.0.2.0/24"}, remoteAddr: "198.51.100.7:1234", want: http.StatusOK},
+		{name: "ipv6 allowed", allow: []string{"2001:db8::/32"}, remoteAddr: "[2001:db8::1]:1234", want: http.StatusOK},
+		{name: "ipv6 not allowed", allow: []string{"2001:db8::/32"}, remoteAddr: "[2001:db9::1]:1234", want: http.StatusForbidden},
+		{name: "ipv6 single address", deny: []string{"2001:db8::1"}, remoteAddr: "[2001:db8::1]:1234", want: http.StatusForbidden},
+		{name: "ipv4-mapped ipv6 matches ipv4 range", allow: []string{"10.0.0.0/8"}, remoteAddr: "[::ffff:10.1.2.3]:1234", want: http.StatusOK},
+		{
+			name: "xff ignored without TRUST_PROXY", allow: []string{"10.0.0.0/8"},
+			remoteAddr: "203.0.113.9:1234", xff: []string{"10.1.2.3"}, want: http.StatusForbidden,
+		},
+		{
+			name: "proxied client allowed", allow: []string{"10.0.0.0/8"}, trustProxy: true,
+			remoteAddr: "172.16.0.1:1234", xff: []string{"10.1.2.3"}, want: http.StatusOK,
+		},
+		{
+			name: "proxied ipv6 client denied", deny: []string{"2001:db8::/32"}, trustProxy: true,
+			remoteAddr: "172.16.0.1:1234", xff: []string{"2001:db8::5"}, want: http.StatusForbidden,
+		},
+		{
+			name: "spoofed leftmost entry ignored", allow: []string{"10.0.0.0/8"}, trustProxy: true,
+			remoteAddr: "172.16.0.1:1234", xff: []string{"10.1.2.3, 203.0.113.9"}, want: http.StatusForbidden,
+		},
+		{
+			name: "spoofed entry in a separate header ignored", allow: []string{"10.0.0.0/8"}, trustProxy: true,
+			remoteAddr: "172.16.0.1:1234", xff: []string{"10.1.2.3", "203.0.113.9"}, want: http.StatusForbidden,
+		},
+		{
+			name: "trusted proxy hops skipped", allow: []string{"10.0.0.0/8"}, trustProxy: true, proxies: []string{"172.16.0.0/12"},
+			remoteAddr: "172.16.0.1:1234", xff: []string{"203.0.113.9, 10.1.2.3, 172.16.0.2"}, want: http.StatusOK,
+		},
+		{
+			name: "spoofed entry behind trusted hops ignored", allow: []string{"10.0.0.0/8"}, trustProxy: true, proxies: []string{"172.16.0.0/12"},
+			remoteAddr: "172.16.0.1:1234", xff: []string{"10.1.2.3, 203.0.113.9, 172.16.0.2"}, want: http.StatusForbidden,
+		},
+		{
+			name: "unparsable hop rejected", allow: []string{"10.0.0.0/8"}, trustProxy: true,
+			remoteAddr: "172.16.0.1:1234", xff: []string{"10.1.2.3, garbage"}, want: http.StatusForbidden,
+		},
+		{
+			name: "remote address used without xff", allow: []string{"10.0.0.0/8"}, trustProxy: true,
+			remoteAddr: "10.1.2.3:1234", want: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &ipFilter{
+				allow:      mustCIDRs(t, tt.allow...),
+				deny:       mustCIDRs(t, tt.deny...),
+				trustProxy: tt.trustProxy,
+				proxies:    mustCIDRs(t, tt.proxies...),
+			}
+			handler := f.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodGet, "/api/models", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, xff := range tt.xff {
+				r.Header.Add("X-Forwarded-For", xff)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCIDRsRejectsInvalidEntries(t *testing.T) {
+	for _, item := range []string{"10.0.0.0/33", "not-an-ip", "2001:db8::/129"} {
+		if _, err := parseCIDRs("ALLOW_CIDRS", []string{item}); err == nil {
+			t.Errorf("parseCIDRs(%q) succeeded, want error", item)
+		}
+	}
+}
+
+func TestIPFilterMiddlewareErrorBody(t *testing.T) {
+	f := &ipFilter{deny: mustCIDRs(t, "192.0.2.0/24")}
+	handler := f.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/models", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != "forbidden" {
+		t.Errorf("body = %q, want a forbidden ErrorResponse", w.Body.String())
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonModePrompt appends instructions to answer with JSON only, matching
+// the schema when one was given.
+func jsonModePrompt(prompt string, rawSchema json.RawMessage) string {
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\nRespond with a single valid JSON value and nothing else: no prose, no code fences.")
+	if len(rawSchema) > 0 {
+		b.WriteString(" The JSON must conform to this JSON Schema:\n")
+		b.Write(rawSchema)
+	}
+	return b.String()
+}
+
+// correctivePrompt asks the model to fix its previous, invalid JSON output.
+func correctivePrompt(prompt, output string, violations []string) string {
+	return fmt.Sprintf("%s\n\nYour previous response was:\n%s\n\nIt was rejected because:\n- %s\n\nRespond again with only the corrected JSON.",
+		prompt, output, strings.Join(violations, "\n- "))
+}
+
+// checkJSONOutput parses the model output as JSON, tolerating surrounding
+// whitespace and a Markdown code fence, and validates it against schema. It
+// returns the cleaned JSON text and any violations.
+func checkJSONOutput(output string, schema *jsonSchema) (string, []string) {
+	cleaned := strings.TrimSpace(output)
+	if strings.HasPrefix(cleaned, "```") {
+		cleaned = strings.TrimPrefix(cleaned, "```json")
+		cleaned = strings.TrimPrefix(cleaned, "```")
+		cleaned = strings.TrimSuffix(cleaned, "```")
+		cleaned = strings.TrimSpace(cleaned)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(cleaned), &value); err != nil {
+		return cleaned, []string{fmt.Sprintf("output is not valid JSON: %v", err)}
+	}
+	return cleaned, schema.validate(value, "$")
+}
+
+// invokeJSON invokes the model with a prompt already carrying the JSON mode
+// instructions, retrying once with a corrective prompt when the output is
+// not valid JSON for the schema. Violations are returned, with a nil error,
+// if the retry also fails validation.
+func invokeJSON(ctx context.Context, svc invoker, modelID, prompt string, schema *jsonSchema) (string, []string, error) {
+	output, err := invokeText(ctx, svc, modelID, prompt)
+	if err != nil {
+		return "", nil, err
+	}
+	cleaned, violations := checkJSONOutput(output, schema)
+	if len(violations) == 0 {
+		return cleaned, nil, nil
+	}
+
+	output, err = invokeText(ctx, svc, modelID, correctivePrompt(prompt, output, violations))
+	if err != nil {
+		return "", nil, err
+	}
+	cleaned, violations = checkJSONOutput(output, schema)
+	return cleaned, violations, nil
+}
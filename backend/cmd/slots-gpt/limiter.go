@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// errModelBusy is returned when no concurrency slot for the model frees up
+// within the queue timeout.
+var errModelBusy = errors.New("model concurrency limit reached")
+
+// modelLimiter caps concurrent invocations per model so a flood of requests
+// to one model cannot starve the others. Invocations over the limit queue
+// for up to queueTimeout. A limit of zero means unlimited.
+type modelLimiter struct {
+	limits       map[string]int
+	defaultLimit int
+	queueTimeout time.Duration
+
+	mu   sync.Mutex
+	sems map[string]*modelSlots
+}
+
+// modelSlots is the semaphore of one model. Semaphores of models without a
+// configured limit are dropped once no caller holds or waits for a slot,
+// as their model names come from clients.
+type modelSlots struct {
+	sem   chan struct{}
+	users int
+}
+
+// loadModelLimiter reads MODEL_CONCURRENCY, a JSON object of model ID to
+// limit, MODEL_CONCURRENCY_DEFAULT for other models, and MODEL_QUEUE_TIMEOUT.
+func loadModelLimiter() (*modelLimiter, error) {
+	l := &modelLimiter{
+		limits:       map[string]int{},
+		defaultLimit: envInt("MODEL_CONCURRENCY_DEFAULT", 0),
+		queueTimeout: envDuration("MODEL_QUEUE_TIMEOUT", 5*time.Second),
+		sems:         map[string]*modelSlots{},
+	}
+	if raw := os.Getenv("MODEL_CONCURRENCY"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &l.limits); err != nil {
+			return nil, fmt.Errorf("invalid MODEL_CONCURRENCY: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// limit returns the concurrency limit for model, zero when unlimited.
+func (l *modelLimiter) limit(model string) int {
+	if limit, ok := l.limits[model]; ok {
+		return limit
+	}
+	return l.defaultLimit
+}
+
+// checkout returns the slots for model, or nil when it is unlimited. Every
+// checkout must be paired with a checkin.
+func (l *modelLimiter) checkout(model string) *modelSlots {
+	limit := l.limit(model)
+	if limit <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slots, ok := l.sems[model]
+	if !ok {
+		slots = &modelSlots{sem: make(chan struct{}, limit)}
+		l.sems[model] = slots
+	}
+	slots.users++
+	return slots
+}
+
+func (l *modelLimiter) checkin(model string, slots *modelSlots) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slots.users--
+	if _, configured := l.limits[model]; slots.users == 0 && !configured {
+		delete(l.sems, model)
+	}
+}
+
+// release returns a function that frees a slot taken from slots.
+func (l *modelLimiter) release(model string, slots *modelSlots) func() {
+	return func() {
+		<-slots.sem
+		l.checkin(model, slots)
+	}
+}
+
+// acquire waits for a slot for model and returns its release function.
+func (l *modelLimiter) acquire(ctx context.Context, model string) (func(), error) {
+	slots := l.checkout(model)
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots.sem <- struct{}{}:
+		return l.release(model, slots), nil
+	default:
+	}
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case slots.sem <- struct{}{}:
+		return l.release(model, slots), nil
+	case <-timer.C:
+		l.checkin(model, slots)
+		return nil, errModelBusy
+	case <-ctx.Done():
+		l.checkin(model, slots)
+		return nil, ctx.Err()
+	}
+}
+
+// tryAcquire takes a slot for model only if one is free right now.
+func (l *modelLimiter) tryAcquire(model string) (func(), bool) {
+	slots := l.checkout(model)
+	if slots == nil {
+		return func() {}, true
+	}
+	select {
+	case slots.sem <- struct{}{}:
+		return l.release(model, slots), true
+	default:
+		l.checkin(model, slots)
+		return nil, false
+	}
+}
+
+// InFlight returns the number of running invocations per limited model
+// that is configured or currently in use.
+func (l *modelLimiter) InFlight() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	inFlight := make(map[string]int, len(l.sems))
+	for model, slots := range l.sems {
+		inFlight[model] = len(slots.sem)
+	}
+	return inFlight
+}
+
+// limitedInvoker holds a model's concurrency slot for the whole invocation,
+// including retries and failover, and records the time spent queued.
+type limitedInvoker struct {
+	invoker
+	limiter *modelLimiter
+}
+
+func (l limitedInvoker) InvokeModelWithContext(ctx aws.Context, input *bedrock.InvokeModelInput, opts ...request.Option) (*bedrock.InvokeModelOutput, error) {
+	start := time.Now()
+	release, err := l.limiter.acquire(ctx, aws.StringValue(input.ModelId))
+	if info := invokeInfoFrom(ctx); info != nil {
+		info.addQueueTime(time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return l.invoker.InvokeModelWithContext(ctx, input, opts...)
+}
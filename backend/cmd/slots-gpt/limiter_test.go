@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func testLimiter(limits map[string]int, defaultLimit int) *modelLimiter {
+	return &modelLimiter{limits: limits, defaultLimit: defaultLimit, queueTimeout: time.Millisecond, sems: map[string]*modelSlots{}}
+}
+
+func TestModelLimiterEnforcesLimit(t *testing.T) {
+	l := testLimiter(map[string]int{"a": 1}, 0)
+	release, err := l.acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := l.acquire(context.Background(), "a"); err != errModelBusy {
+		t.Errorf("second acquire err = %v, want errModelBusy", err)
+	}
+	if _, ok := l.tryAcquire("a"); ok {
+		t.Error("tryAcquire succeeded with the only slot taken")
+	}
+	release()
+	if release, ok := l.tryAcquire("a"); !ok {
+		t.Error("tryAcquire failed after release")
+	} else {
+		release()
+	}
+}
+
+func TestModelLimiterDropsIdleDefaultSemaphores(t *testing.T) {
+	l := testLimiter(map[string]int{"configured": 2}, 1)
+	held, err := l.acquire(context.Background(), "held")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	for _, model := range []string{"configured", "client-model-1", "client-model-2"} {
+		release, err := l.acquire(context.Background(), model)
+		if err != nil {
+			t.Fatalf("acquire(%s): %v", model, err)
+		}
+		release()
+	}
+	if _, ok := l.tryAcquire("client-model-3"); !ok {
+		t.Fatal("tryAcquire failed on an idle model")
+	}
+
+	want := map[string]int{"configured": 0, "held": 1, "client-model-3": 1}
+	if got := l.InFlight(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("InFlight() = %v, want %v", got, want)
+	}
+	held()
+	delete(want, "held")
+	if got := l.InFlight(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("InFlight() after release = %v, want %v", got, want)
+	}
+}
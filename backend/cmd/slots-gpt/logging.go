@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+// debugLogging enables debug-level log lines. main sets it from
+// LOG_LEVEL=debug once the .env file is loaded.
+var debugLogging bool
+
+// debugf logs like logf when debug logging is enabled.
+func debugf(ctx context.Context, format string, args ...interface{}) {
+	if debugLogging {
+		logf(ctx, "DEBUG "+format, args...)
+	}
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -15,21 +21,51 @@ import (
 )
 
 type PromptRequest struct {
-	Prompt string `json:"prompt"`
-	Model  string `json:"model"`
+	Prompt   string           `json:"prompt"`
+	Model    string           `json:"model"`
+	Examples []FewShotExample `json:"examples,omitempty"`
+
+	// JSONMode asks for JSON output, validated against ResponseSchema when
+	// one is given.
+	JSONMode       bool            `json:"json_mode,omitempty"`
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty"`
+
+	// Chunk splits a prompt too long for the context window into chunks,
+	// processed map-reduce style.
+	Chunk bool `json:"chunk,omitempty"`
 }
 
 type PromptResponse struct {
-	Response string `json:"response"`
+	Response string       `json:"response"`
+	Chunks   []ChunkUsage `json:"chunks,omitempty"`
+	Flagged  bool         `json:"flagged,omitempty"`
 }
 
 func main() {
-	// Load environment variables
+	configPath := flag.String("config", "", "path to a JSON config file, or a flat key: value YAML subset file (.yaml, .yml); environment variables take precedence")
+	flag.Parse()
+
+	// Load environment variables; .env never overrides the real environment
+	processEnv := environNames()
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
+	if *configPath != "" {
+		cfg, err := loadConfigFile(*configPath)
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		if err := cfg.applyToEnv(); err != nil {
+			log.Fatalf("Failed to apply config: %v", err)
+		}
+	}
+	debugLogging = strings.EqualFold(os.Getenv("LOG_LEVEL"), "debug")
 
-	awsRegion := os.Getenv("AWS_REGION")
+	primaryRegion := os.Getenv("AWS_REGION_PRIMARY")
+	if primaryRegion == "" {
+		primaryRegion = os.Getenv("AWS_REGION")
+	}
+	secondaryRegion := os.Getenv("AWS_REGION_SECONDARY")
 	awsAccessKey := os.Getenv("AWS_ACCESS_KEY_ID")
 	awsSecretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
 	port := os.Getenv("PORT")
@@ -37,54 +73,316 @@ func main() {
 		port = "3000"
 	}
 
-	sess, err := session.NewSession(&aws.Config{
-		Region:      aws.String(awsRegion),
+	profiles, err := loadInferenceProfiles(os.Getenv("INFERENCE_PROFILE_MAP"))
+	if err != nil {
+		log.Fatalf("Failed to load inference profiles: %v", err)
+	}
+
+	routes, err := loadModelRoutes(os.Getenv("MODEL_ROUTES"))
+	if err != nil {
+		log.Fatalf("Failed to load model routes: %v", err)
+	}
+
+	capabilities, err := loadCapabilities(os.Getenv("MODEL_CAPABILITIES"))
+	if err != nil {
+		log.Fatalf("Failed to load model capabilities: %v", err)
+	}
+
+	ipFilter, err := loadIPFilter()
+	if err != nil {
+		log.Fatalf("Failed to load IP filter: %v", err)
+	}
+
+	cors, err := loadCORSConfig()
+	if err != nil {
+		log.Fatalf("Failed to load CORS config: %v", err)
+	}
+	forwardedHeaders := loadForwardedHeaders()
+
+	fewShot, err := loadFewShotFormatter()
+	if err != nil {
+		log.Fatalf("Failed to load few-shot formatter: %v", err)
+	}
+
+	capture, err := loadCapturer(awsAccessKey, awsSecretKey)
+	if err != nil {
+		log.Fatalf("Failed to set up request capture: %v", err)
+	}
+
+	injection, err := loadInjectionDetector()
+	if err != nil {
+		log.Fatalf("Failed to load injection detector: %v", err)
+	}
+
+	limiter, err := loadModelLimiter()
+	if err != nil {
+		log.Fatalf("Failed to load model concurrency limits: %v", err)
+	}
+
+	chunker, err := loadChunker(capabilities, limiter)
+	if err != nil {
+		log.Fatalf("Failed to load chunker: %v", err)
+	}
+
+	retry := loadRetryPolicy()
+	sampler := loadPromptSampler()
+
+	flags := newFeatureFlags("ENABLE_UI", "MAINTENANCE_MODE", "ENABLE_INJECTION_DETECTION", "INJECTION_BLOCK", "OUTPUT_MODERATION")
+	flags.reloadOnSIGHUP(processEnv, *configPath)
+	log.Printf("Feature flags: %s", flags)
+
+	transforms, err := buildTransformChain(
+		injectionTransformer{detector: injection, flags: flags},
+		fewShotTransformer{formatter: fewShot},
+		jsonModeTransformer{},
+	)
+	if err != nil {
+		log.Fatalf("Failed to build prompt transformers: %v", err)
+	}
+
+	awsConfig := &aws.Config{
+		Region:      aws.String(primaryRegion),
 		Credentials: credentials.NewStaticCredentials(awsAccessKey, awsSecretKey, ""),
-	})
+	}
+	if endpoint := os.Getenv("AWS_BEDROCK_ENDPOINT"); endpoint != "" {
+		log.Printf("WARNING: using custom Bedrock endpoint %s instead of the AWS service endpoint; unset AWS_BEDROCK_ENDPOINT in production", endpoint)
+		awsConfig.Endpoint = aws.String(endpoint)
+	}
+
+	for _, name := range credentialEnvVars {
+		debugf(context.Background(), "Credential %s present: %t", name, os.Getenv(name) != "")
+	}
+
+	sess, err := session.NewSession(awsConfig)
 	if err != nil {
-		log.Fatalf("Failed to create AWS session: %v", err)
+		log.Fatalf("Failed to create AWS session: %v", scrubSecrets(err, awsAccessKey, awsSecretKey))
 	}
 
 	svc := bedrock.New(sess)
+	// retryingInvoker owns invoke retries; SDK retries on top would multiply
+	// them. Control-plane calls keep the SDK retries on svc.
+	invokeSvc := bedrock.New(sess, aws.NewConfig().WithMaxRetries(0))
+	shedder := loadLoadShedder()
+	normalizer := &modelNormalizer{svc: svc, routes: routes, profiles: profiles, capabilities: capabilities}
+	bedrockInvoker := &failoverInvoker{primary: regionalInvoker{
+		invoker: retryingInvoker{invoker: timedInvoker{invoker: invokeSvc, shedder: shedder}, policy: retry},
+		Region:  primaryRegion,
+	}}
+	if secondaryRegion != "" {
+		secondarySvc := bedrock.New(sess, aws.NewConfig().WithRegion(secondaryRegion).WithMaxRetries(0))
+		bedrockInvoker.secondary = &regionalInvoker{
+			invoker: retryingInvoker{invoker: timedInvoker{invoker: secondarySvc, shedder: shedder}, policy: retry},
+			Region:  secondaryRegion,
+		}
+		log.Printf("Bedrock region failover enabled: %s -> %s", primaryRegion, secondaryRegion)
+	}
+	limited := limitedInvoker{invoker: bedrockInvoker, limiter: limiter}
+
+	moderator, err := loadOutputModerator(flags, limited, profiles)
+	if err != nil {
+		log.Fatalf("Failed to load output moderation: %v", err)
+	}
+
+	warm, err := loadWarmer(svc, limiter, profiles)
+	if err != nil {
+		log.Fatalf("Failed to load Bedrock warm-up: %v", err)
+	}
+	if warm != nil {
+		log.Printf("Bedrock warm-up enabled: %s every %s", warm.model, warm.interval)
+		warm.start()
+	}
 
-	http.HandleFunc("/api/send-prompt", func(w http.ResponseWriter, r *http.Request) {
+	trailers := envBool("RESPONSE_TRAILERS")
+	maxRequestBytes := int64(envInt("MAX_REQUEST_BYTES", 1<<20))
+	sendPrompt := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			writeAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Invalid request method")
+			return
+		}
+
+		start := time.Now()
+		ctx, info := withInvokeInfo(r.Context())
+
+		req, version, err := decodePromptRequest(r.Header.Get("X-API-Version"), r.Body)
+		if err != nil {
+			writeDecodeError(w, err)
 			return
 		}
+		w.Header().Set("X-API-Version", version)
 
-		var req PromptRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		req.Model = strings.TrimSpace(req.Model)
+		if strings.TrimSpace(req.Prompt) == "" {
+			writeAPIError(w, http.StatusBadRequest, "missing_prompt", `The "prompt" field is required and must contain non-whitespace text`)
+			return
+		}
+		if req.Model == "" {
+			writeAPIError(w, http.StatusBadRequest, "missing_model", `The "model" field is required; list available models with GET /api/models`)
 			return
 		}
 
-		if req.Prompt == "" || req.Model == "" {
-			http.Error(w, "Prompt and model are required", http.StatusBadRequest)
+		if conflicts := findConflicts(req); len(conflicts) > 0 {
+			writeAPIError(w, http.StatusBadRequest, "conflicting_fields", conflictError(conflicts))
 			return
 		}
 
-		params := &bedrock.InvokeModelInput{
-			InputText: aws.String(req.Prompt),
-			ModelId:  aws.String(req.Model),
+		var schema *jsonSchema
+		if req.JSONMode {
+			if schema, err = parseSchema(req.ResponseSchema); err != nil {
+				writeAPIError(w, http.StatusBadRequest, "invalid_schema", err.Error())
+				return
+			}
 		}
 
-		resp, err := svc.InvokeModel(params)
+		req, err = transforms.apply(ctx, req)
 		if err != nil {
-			log.Printf("Error invoking Bedrock model: %v", err)
-			http.Error(w, "Failed to invoke Bedrock model", http.StatusInternalServerError)
+			var reqErr *requestError
+			if errors.As(err, &reqErr) {
+				writeAPIError(w, reqErr.Status, reqErr.Code, reqErr.Message)
+				return
+			}
+			logf(r.Context(), "Error transforming prompt: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, "internal_error", "Failed to prepare prompt")
+			return
+		}
+		info.writeHeaders(w)
+
+		model, err := normalizer.normalize(r.Context(), req.Model)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "unknown_model", err.Error())
+			return
+		}
+
+		modelID, err := resolveModelID(routes.pick(model), profiles)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid_model", err.Error())
+			return
+		}
+		w.Header().Set("X-Model-Used", modelID)
+
+		prompt := req.Prompt
+
+		if req.Chunk {
+			err = chunker.validate(prompt)
+		} else {
+			err = validatePromptLength(capabilities, modelID, prompt)
+		}
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "prompt_too_long", err.Error())
+			return
+		}
+
+		var output string
+		var violations []string
+		var chunks []ChunkUsage
+		invokeStart := time.Now()
+		if req.Chunk {
+			output, chunks, err = chunker.run(ctx, limited, modelID, prompt)
+		} else if req.JSONMode {
+			output, violations, err = invokeJSON(ctx, limited, modelID, prompt, schema)
+		} else {
+			output, err = invokeText(ctx, limited, modelID, prompt)
+		}
+		setServerTiming(w,
+			timingEntry{"parse", "Request decoding and validation", invokeStart.Sub(start)},
+			timingEntry{"queue", "Waiting for a model concurrency slot", info.QueueTime()},
+			timingEntry{"invoke", "Model invocation including retries", time.Since(invokeStart)},
+			timingEntry{"bedrock", "Time in Bedrock calls", info.BedrockTime()},
+		)
+		sampler.log(ctx, modelID, prompt, output, err)
+		if region := info.Region(); region != "" {
+			w.Header().Set("X-Region-Used", region)
+		}
+		if errors.Is(err, errModelBusy) {
+			w.Header().Set("Retry-After", "1")
+			writeAPIError(w, http.StatusServiceUnavailable, "model_busy", "Model is at its concurrency limit, try again later")
+			return
+		}
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			writeAPIError(w, reqErr.Status, reqErr.Code, reqErr.Message)
+			return
+		}
+		if err != nil {
+			requestID := awsRequestID(err)
+			logf(r.Context(), "Error invoking Bedrock model (aws_request_id=%s): %v", requestID, err)
+			writeError(w, http.StatusInternalServerError, APIError{
+				Code:         "bedrock_error",
+				Message:      "Failed to invoke Bedrock model",
+				AWSRequestID: requestID,
+			})
+			return
+		}
+
+		if len(violations) > 0 {
+			writeError(w, http.StatusUnprocessableEntity, APIError{
+				Code:             "schema_mismatch",
+				Message:          "Model output did not match the response schema",
+				ValidationErrors: violations,
+			})
+			return
+		}
+
+		inputTokens, outputTokens := promptUsage(prompt, output, chunks)
+		if trailers {
+			announceUsageTrailers(w)
+			defer func() { writeUsageTrailers(w, inputTokens, outputTokens, time.Since(start)) }()
+		}
+
+		flagged, err := moderator.check(ctx, output)
+		if err != nil {
+			logf(r.Context(), "Error moderating model output: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, "moderation_error", "Failed to moderate model output")
+			return
+		}
+		if flagged {
+			output = moderator.placeholder
+			w.Header().Set("X-Output-Flagged", "true")
+		}
+
+		if wantsPlainText(r) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, output)
 			return
 		}
 
 		response := PromptResponse{
-			Response: aws.StringValue(resp.OutputText),
+			Response: output,
+			Chunks:   chunks,
+			Flagged:  flagged,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	})
 
+	http.Handle("/api/send-prompt", shedder.middleware(limitBody(maxRequestBytes, capture.middleware(sendPrompt))))
+	if capture.dir != "" {
+		http.Handle("/api/replay", adminMiddleware(shedder.middleware(limitBody(maxRequestBytes, replayHandler(sendPrompt)))))
+	}
+
+	http.Handle("/api/models", modelsHandler(svc, profiles))
+	http.Handle("/api/access-check", adminMiddleware(accessCheckHandler(svc, profiles)))
+	beat := startHeartbeat(time.Second, envDuration("LIVENESS_TIMEOUT", 10*time.Second))
+	probe := &bedrockProbe{svc: svc, interval: envDuration("READINESS_CHECK_INTERVAL", 30*time.Second)}
+	http.Handle("/healthz", healthzHandler(beat, shedder, bedrockInvoker, limiter))
+	http.Handle("/readyz", readyzHandler(flags, probe, shedder))
+
+	ui := uiHandler()
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if !flags.Enabled("ENABLE_UI") {
+			http.NotFound(w, r)
+			return
+		}
+		ui.ServeHTTP(w, r)
+	})
+
 	log.Printf("Server is running on port %s", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), nil))
+	// Middleware listed first runs innermost.
+	var handler http.Handler = http.DefaultServeMux
+	handler = metadataMiddleware(forwardedHeaders, handler)
+	handler = maintenanceMiddleware(flags, handler)
+	handler = corsMiddleware(cors, handler)
+	handler = ipFilter.middleware(handler)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), handler))
 }
 
 /**
@@ -93,19 +391,104 @@ func main() {
  *    AWS_ACCESS_KEY_ID=<your_aws_access_key_id>
  *    AWS_SECRET_ACCESS_KEY=<your_aws_secret_access_key>
  *    AWS_REGION=<your_aws_region>
+ *    AWS_REGION_PRIMARY=<optional, overrides AWS_REGION as the region tried first>
+ *    AWS_REGION_SECONDARY=<optional region to fail over to on regional errors>
  *    PORT=<optional_port>
+ *    LOG_LEVEL=<optional, debug for debug logging; credential values are never logged>
+ *    LOG_SAMPLE_RATE=<optional 0.0-1.0 fraction of prompts and responses logged at debug level; failed requests are always logged when set>
+ *    AWS_BEDROCK_ENDPOINT=<optional endpoint override, e.g. a local Bedrock mock for integration tests>
+ *    ADMIN_TOKEN=<optional bearer token for admin endpoints such as /api/access-check; they are disabled when unset>
+ *    INFERENCE_PROFILE_MAP=<optional JSON object mapping model aliases to inference profile / provisioned throughput ARNs>
+ *    MODEL_ROUTES=<optional JSON object of logical model name to [{"model": ID, "weight": N}] for weighted routing>
+ *    MODEL_CAPABILITIES=<optional JSON object of model ID to {"max_input_tokens": N}, merged over the built-in table>
+ *    ALLOW_CIDRS=<optional comma-separated CIDRs or IPs allowed to connect; everyone when unset>
+ *    DENY_CIDRS=<optional comma-separated CIDRs or IPs to reject, taking precedence over ALLOW_CIDRS>
+ *    TRUST_PROXY=<optional, true to take the client IP from X-Forwarded-For, walking it from the right>
+ *    TRUSTED_PROXY_CIDRS=<optional comma-separated CIDRs of proxy hops to skip in X-Forwarded-For>
+ *    CORS_ALLOW_ORIGINS=<optional comma-separated browser origins, or *>
+ *    CORS_ALLOW_CREDENTIALS=<optional, true to allow credentialed requests; requires explicit origins, not *>
+ *    CORS_EXPOSE_HEADERS=<optional comma-separated response headers readable by browsers>
+ *    FORWARD_HEADERS=<optional comma-separated request headers to add to log lines, e.g. X-Tenant-Id=acme|globex,X-Trace-Id>
+ *    PROMPT_TRANSFORMERS=<optional comma-separated order of prompt transformers, naming each exactly once; defaults to injection,few_shot,json_mode>
+ *    FEW_SHOT_TEMPLATE=<optional text/template over .Examples and .Prompt>
+ *    MAX_FEW_SHOT_EXAMPLES=<optional, defaults to 8>
+ *    CHUNK_SIZE_TOKENS=<optional chunk size for "chunk": true requests, defaults to 4000>
+ *    CHUNK_OVERLAP_TOKENS=<optional overlap between chunks, defaults to 200>
+ *    CHUNK_CONCURRENCY=<optional chunks invoked in parallel, defaults to 4>
+ *    CHUNK_MAX_CHUNKS=<optional chunk count above which chunked prompts are rejected with 400, defaults to 20>
+ *    CHUNK_MAP_TEMPLATE=<optional text/template per chunk over .Index, .Total and .Text>
+ *    CHUNK_COMBINE_TEMPLATE=<optional text/template combining chunk results over .Results>
+ *    BEDROCK_MAX_RETRIES=<optional, defaults to 3>
+ *    BEDROCK_RETRY_BASE_DELAY=<optional, defaults to 200ms>
+ *    BEDROCK_RETRY_MAX_DELAY=<optional, defaults to 5s>
+ *    MODEL_CONCURRENCY=<optional JSON object of model ID to max concurrent invocations>
+ *    MODEL_CONCURRENCY_DEFAULT=<optional limit for other models, unlimited by default>
+ *    MODEL_QUEUE_TIMEOUT=<optional wait for a slot before answering 503, defaults to 5s>
+ *    WARMUP_INTERVAL=<optional interval between warm-up invokes, e.g. 5m; disabled by default>
+ *    WARMUP_MODEL=<optional model ID or alias to warm up; required with WARMUP_INTERVAL>
+ *    SHED_LATENCY_THRESHOLD=<optional Bedrock p99 latency, e.g. 10s, above which requests are shed; disabled by default>
+ *    SHED_FRACTION=<optional fraction of requests rejected while shedding, defaults to 0.5>
+ *    SHED_WINDOW=<optional window for the rolling p99, defaults to 1m>
+ *    LIVENESS_TIMEOUT=<optional heartbeat age after which /healthz fails, defaults to 10s>
+ *    READINESS_CHECK_INTERVAL=<optional cache time for the /readyz Bedrock check, defaults to 30s>
+ *    MAX_REQUEST_BYTES=<optional maximum send-prompt and replay body size, defaults to 1048576>
+ *    RESPONSE_TRAILERS=<optional, true to send estimated token usage and duration as HTTP trailers>
+ *    CAPTURE_DIR=<optional directory to capture scrubbed request/response pairs to; disabled by default>
+ *    CAPTURE_MAX_FILES=<optional, defaults to 1000>
+ *    CAPTURE_MAX_MB=<optional, defaults to 100>
+ *    ENABLE_UI=<optional, true to serve a test page at />
+ *    MAINTENANCE_MODE=<optional, true to answer /api/* and /readyz with 503>
+ *    ENABLE_INJECTION_DETECTION=<optional, true to flag suspected prompt injections with X-Injection-Suspected>
+ *    INJECTION_BLOCK=<optional, true to reject suspected prompt injections with 422 instead>
+ *    INJECTION_PATTERNS=<optional JSON array of regular expressions replacing the built-in patterns>
+ *    OUTPUT_MODERATION=<optional, true to re-check model output and withhold flagged responses>
+ *    MODERATION_MODEL=<optional guard model ID or alias that scores output from 0 to 1>
+ *    MODERATION_THRESHOLD=<optional guard score at or above which output is flagged, defaults to 0.5>
+ *    MODERATION_PATTERNS=<optional JSON array of regular expressions that flag output locally>
+ *    MODERATION_PLACEHOLDER=<optional text returned instead of flagged output>
+ *
+ *    Feature flags (ENABLE_UI, MAINTENANCE_MODE, ENABLE_INJECTION_DETECTION,
+ *    INJECTION_BLOCK, OUTPUT_MODERATION) can be changed in .env or the -config
+ *    file and reloaded with SIGHUP; flags set in the real environment keep
+ *    their value. Other settings are only read at startup.
+ *
+ *    Alternatively pass -config <file.json|file.yaml> with the same settings
+ *    under lowercased keys (e.g. "port", "model_routes"). .yaml files use a
+ *    flat subset of YAML, not full YAML: top-level "key: value" lines only,
+ *    with structured values such as model_routes written as inline JSON and
+ *    JSON escapes in double-quoted strings. Boolean settings must be true
+ *    or false. Credentials stay in the environment; environment and .env
+ *    values override the file.
  * 
  * 2. Install dependencies:
  *    go get github.com/aws/aws-sdk-go github.com/joho/godotenv
  * 
  * 3. Run the app:
- *    go run main.go
+ *    go run ./cmd/slots-gpt   (from the backend directory)
  *
  * 4. Send POST requests to http://localhost:<port>/api/send-prompt
  *    with JSON payloads like:
  *    {
  *      "prompt": "Hello, Bedrock!",
- *      "model": "example-model-id"
+ *      "model": "example-model-id",
+ *      "examples": [{"input": "Hi", "output": "Hello!"}]
  *    }
+ *    Send "Accept: text/plain" to get the bare completion instead of JSON.
+ *    Set "json_mode": true, optionally with a "response_schema", to get
+ *    validated JSON output; a 422 is returned if the model cannot comply.
+ *    Pin the request schema with an "X-API-Version: 1" header or a
+ *    "version" field; the latest version is used when neither is given.
+ *    Errors from /api/ endpoints have the body
+ *    {"error": {"code": "...", "message": "..."}}.
+ *
+ * 5. List available models with GET http://localhost:<port>/api/models
+ *
+ * 6. Re-run a file captured to CAPTURE_DIR by POSTing it to
+ *    http://localhost:<port>/api/replay with "Authorization: Bearer
+ *    <ADMIN_TOKEN>"; the endpoint only exists while CAPTURE_DIR is set
+ *
+ * 7. Check that the credentials can use a model, without invoking it, with
+ *    GET http://localhost:<port>/api/access-check?model=<model ID or alias>
+ *    and "Authorization: Bearer <ADMIN_TOKEN>"
  */
 
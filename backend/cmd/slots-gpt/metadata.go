@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// forwardedHeader is an incoming header copied into request metadata. When
+// Allowed is set, other values are dropped so the field stays low-cardinality.
+type forwardedHeader struct {
+	Name    string
+	Allowed map[string]bool
+}
+
+// loadForwardedHeaders parses FORWARD_HEADERS, a comma-separated list of
+// header names, each optionally restricted to a |-separated set of values,
+// e.g. "X-Tenant-Id=acme|globex,X-Trace-Id".
+func loadForwardedHeaders() []forwardedHeader {
+	var headers []forwardedHeader
+	for _, item := range envList("FORWARD_HEADERS") {
+		name, values, restricted := strings.Cut(item, "=")
+		h := forwardedHeader{Name: http.CanonicalHeaderKey(strings.TrimSpace(name))}
+		if restricted {
+			h.Allowed = map[string]bool{}
+			for _, v := range strings.Split(values, "|") {
+				if v = strings.TrimSpace(v); v != "" {
+					h.Allowed[v] = true
+				}
+			}
+		}
+		headers = append(headers, h)
+	}
+	return headers
+}
+
+type metadataKey struct{}
+
+// metadataMiddleware stores the configured headers of each request in its
+// context, where logf picks them up.
+func metadataMiddleware(headers []forwardedHeader, next http.Handler) http.Handler {
+	if len(headers) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metadata := map[string]string{}
+		for _, h := range headers {
+			v := r.Header.Get(h.Name)
+			if v == "" || (h.Allowed != nil && !h.Allowed[v]) {
+				continue
+			}
+			metadata[strings.ToLower(h.Name)] = v
+		}
+		if len(metadata) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), metadataKey{}, metadata))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestMetadata returns the forwarded header values attached to ctx.
+func requestMetadata(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(metadataKey{}).(map[string]string)
+	return metadata
+}
+
+// logf logs like log.Printf, followed by the request's metadata as
+// key=value fields.
+func logf(ctx context.Context, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	metadata := requestMetadata(ctx)
+	if len(metadata) == 0 {
+		log.Print(msg)
+		return
+	}
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%q", k, metadata[k])
+	}
+	log.Print(b.String())
+}
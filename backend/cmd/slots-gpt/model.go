@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// modelARNPattern matches Bedrock model ARNs: foundation models, custom and
+// imported models, provisioned throughput and (application) inference profiles.
+var modelARNPattern = regexp.MustCompile(`^arn:aws(-[a-z]+)*:bedrock:[a-z0-9-]+:(\d{12})?:(foundation-model|custom-model|imported-model|provisioned-model|inference-profile|application-inference-profile)/[A-Za-z0-9._:/-]+$`)
+
+// loadInferenceProfiles parses INFERENCE_PROFILE_MAP, a JSON object mapping
+// model aliases to inference profile or provisioned throughput ARNs.
+func loadInferenceProfiles(raw string) (map[string]string, error) {
+	profiles := map[string]string{}
+	if raw == "" {
+		return profiles, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &profiles); err != nil {
+		return nil, fmt.Errorf("invalid INFERENCE_PROFILE_MAP: %w", err)
+	}
+	for alias, arn := range profiles {
+		if !modelARNPattern.MatchString(arn) {
+			return nil, fmt.Errorf("invalid INFERENCE_PROFILE_MAP: alias %q maps to invalid model ARN %q", alias, arn)
+		}
+	}
+	return profiles, nil
+}
+
+// resolveModelID returns the ModelId to send to Bedrock for the requested
+// model. Aliases resolve through the inference profile map; ARNs are validated
+// and passed through as is, like plain on-demand model IDs.
+func resolveModelID(model string, profiles map[string]string) (string, error) {
+	if arn, ok := profiles[model]; ok {
+		return arn, nil
+	}
+	if strings.HasPrefix(model, "arn:") && !modelARNPattern.MatchString(model) {
+		return "", fmt.Errorf("invalid model ARN %q", model)
+	}
+	return model, nil
+}
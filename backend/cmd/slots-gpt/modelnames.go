@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// displayNames maps common display names, lowercased, to model IDs. Names
+// from the Bedrock model catalog are added to these at runtime.
+var displayNames = map[string]string{
+	"claude 2":           "anthropic.claude-v2",
+	"claude 2.1":         "anthropic.claude-v2:1",
+	"claude instant":     "anthropic.claude-instant-v1",
+	"claude 3 haiku":     "anthropic.claude-3-haiku-20240307-v1:0",
+	"claude 3 sonnet":    "anthropic.claude-3-sonnet-20240229-v1:0",
+	"titan text express": "amazon.titan-text-express-v1",
+	"titan text lite":    "amazon.titan-text-lite-v1",
+	"jurassic-2 mid":     "ai21.j2-mid-v1",
+	"jurassic-2 ultra":   "ai21.j2-ultra-v1",
+	"command":            "cohere.command-text-v14",
+	"llama 2 chat 13b":   "meta.llama2-13b-chat-v1",
+	"llama 2 chat 70b":   "meta.llama2-70b-chat-v1",
+}
+
+// modelNormalizer turns what clients paste into the model field, such as a
+// console URL or a display name, into a model ID, and suggests close
+// matches for names it does not recognise.
+type modelNormalizer struct {
+	svc          *bedrock.Bedrock
+	routes       modelRoutes
+	profiles     map[string]string
+	capabilities map[string]modelCapabilities
+
+	mu         sync.Mutex
+	catalog    map[string]string // model ID -> lowercased display name
+	loadedAt   time.Time
+	refreshing bool
+}
+
+// regionProfilePrefixes start cross-region inference profile IDs such as
+// us.anthropic.claude-3-5-sonnet-20240620-v1:0, which ListFoundationModels
+// does not return.
+var regionProfilePrefixes = []string{"us.", "us-gov.", "eu.", "apac."}
+
+// catalogTTL is how long the Bedrock model catalog is cached, and
+// catalogRetry how soon a failed load is retried.
+const (
+	catalogTTL   = 10 * time.Minute
+	catalogRetry = time.Minute
+)
+
+// loadCatalog returns the cached model catalog, refreshing it when stale. It
+// returns nil when the catalog could not be loaded. Only one caller
+// refreshes at a time, outside the lock and detached from its request, so
+// other requests keep using the cached catalog and a cancelled request
+// cannot fail the refresh.
+func (n *modelNormalizer) loadCatalog(ctx context.Context) map[string]string {
+	n.mu.Lock()
+	ttl := catalogTTL
+	if n.catalog == nil {
+		ttl = catalogRetry
+	}
+	if n.refreshing || time.Since(n.loadedAt) < ttl {
+		defer n.mu.Unlock()
+		return n.catalog
+	}
+	n.refreshing = true
+	n.mu.Unlock()
+
+	catalog, err := n.fetchCatalog()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.refreshing = false
+	n.loadedAt = time.Now()
+	if err != nil {
+		logf(ctx, "Failed to load Bedrock model catalog: %v", err)
+		return n.catalog
+	}
+	n.catalog = catalog
+	return n.catalog
+}
+
+func (n *modelNormalizer) fetchCatalog() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := n.svc.ListFoundationModelsWithContext(ctx, &bedrock.ListFoundationModelsInput{})
+	if err != nil {
+		return nil, err
+	}
+	catalog := make(map[string]string, len(out.ModelSummaries))
+	for _, m := range out.ModelSummaries {
+		catalog[aws.StringValue(m.ModelId)] = strings.ToLower(aws.StringValue(m.ModelName))
+	}
+	return catalog, nil
+}
+
+// cleanModelName extracts a model ID from a pasted console URL and strips
+// surrounding noise.
+func cleanModelName(model string) string {
+	model = strings.TrimSpace(model)
+	if u, err := url.Parse(model); err == nil && u.Scheme != "" && u.Host != "" {
+		for _, key := range []string{"modelId", "model"} {
+			if v := u.Query().Get(key); v != "" {
+				return v
+			}
+		}
+		// The console keeps its route in the fragment, e.g.
+		// #/providers?model=anthropic.claude-v2 or #/model-catalog/.../anthropic.claude-v2
+		if frag, err := url.Parse(u.Fragment); err == nil && u.Fragment != "" {
+			for _, key := range []string{"modelId", "model"} {
+				if v := frag.Query().Get(key); v != "" {
+					return v
+				}
+			}
+			return path.Base(frag.Path)
+		}
+		return path.Base(u.Path)
+	}
+	model = strings.TrimPrefix(model, "bedrock/")
+	model = strings.TrimPrefix(model, "bedrock:")
+	return strings.Trim(model, `/"'`)
+}
+
+// normalize returns the model ID for the requested model. Route names,
+// inference profile aliases, ARNs and cross-region inference profile IDs
+// are returned unchanged. Unknown names
+// are rejected with suggestions, but only when the Bedrock catalog is
+// available to check against.
+func (n *modelNormalizer) normalize(ctx context.Context, model string) (string, error) {
+	model = cleanModelName(model)
+	if _, ok := n.routes[model]; ok {
+		return model, nil
+	}
+	if _, ok := n.profiles[model]; ok || strings.HasPrefix(model, "arn:") {
+		return model, nil
+	}
+	for _, prefix := range regionProfilePrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return model, nil
+		}
+	}
+	if _, ok := n.capabilities[model]; ok {
+		return model, nil
+	}
+
+	name := strings.ToLower(strings.Join(strings.Fields(model), " "))
+	if id, ok := displayNames[name]; ok {
+		return id, nil
+	}
+
+	catalog := n.loadCatalog(ctx)
+	if catalog == nil {
+		return model, nil
+	}
+	if _, ok := catalog[model]; ok {
+		return model, nil
+	}
+	for id, displayName := range catalog {
+		if displayName == name {
+			return id, nil
+		}
+	}
+
+	suggestions := n.suggest(model, catalog)
+	if len(suggestions) == 0 {
+		return "", fmt.Errorf("unknown model %q", model)
+	}
+	return "", fmt.Errorf("unknown model %q, did you mean: %s?", model, strings.Join(suggestions, ", "))
+}
+
+// suggest returns up to three known model names closest to model by edit
+// distance, ignoring any that are too far off to be useful.
+func (n *modelNormalizer) suggest(model string, catalog map[string]string) []string {
+	var candidates []string
+	for id := range catalog {
+		candidates = append(candidates, id)
+	}
+	for name := range n.routes {
+		candidates = append(candidates, name)
+	}
+	for alias := range n.profiles {
+		candidates = append(candidates, alias)
+	}
+
+	type match struct {
+		name     string
+		distance int
+	}
+	maxDistance := max(3, len(model)/3)
+	var matches []match
+	lower := strings.ToLower(model)
+	for _, c := range candidates {
+		if d := levenshtein(lower, strings.ToLower(c)); d <= maxDistance {
+			matches = append(matches, match{c, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var suggestions []string
+	for i := 0; i < len(matches) && i < 3; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
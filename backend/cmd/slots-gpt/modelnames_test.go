@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cachedNormalizer has a fresh catalog, so normalize never calls Bedrock.
+func cachedNormalizer() *modelNormalizer {
+	return &modelNormalizer{
+		profiles: map[string]string{"fast": "arn:aws:bedrock:us-east-1:123456789012:inference-profile/fast"},
+		catalog: map[string]string{
+			"anthropic.claude-3-5-sonnet-20240620-v1:0": "claude 3.5 sonnet",
+			"amazon.titan-text-express-v1":              "titan text g1 - express",
+		},
+		loadedAt: time.Now(),
+	}
+}
+
+func TestNormalizeModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"anthropic.claude-3-5-sonnet-20240620-v1:0", "anthropic.claude-3-5-sonnet-20240620-v1:0"},
+		{"us.anthropic.claude-3-5-sonnet-20240620-v1:0", "us.anthropic.claude-3-5-sonnet-20240620-v1:0"},
+		{"eu.anthropic.claude-3-5-sonnet-20240620-v1:0", "eu.anthropic.claude-3-5-sonnet-20240620-v1:0"},
+		{"apac.anthropic.claude-3-5-sonnet-20240620-v1:0", "apac.anthropic.claude-3-5-sonnet-20240620-v1:0"},
+		{"arn:aws:bedrock:us-east-1::foundation-model/amazon.titan-text-express-v1", "arn:aws:bedrock:us-east-1::foundation-model/amazon.titan-text-express-v1"},
+		{"fast", "fast"},
+		{"Claude 3.5  Sonnet", "anthropic.claude-3-5-sonnet-20240620-v1:0"},
+		{"Claude 2", "anthropic.claude-v2"},
+		{"https://console.aws.amazon.com/bedrock/home#/providers?model=amazon.titan-text-express-v1", "amazon.titan-text-express-v1"},
+	}
+	n := cachedNormalizer()
+	for _, tt := range tests {
+		got, err := n.normalize(context.Background(), tt.model)
+		if err != nil || got != tt.want {
+			t.Errorf("normalize(%q) = %q, %v; want %q", tt.model, got, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeModelSuggestsCloseMatches(t *testing.T) {
+	_, err := cachedNormalizer().normalize(context.Background(), "amazon.titan-text-expres-v1")
+	if err == nil || !strings.Contains(err.Error(), "amazon.titan-text-express-v1") {
+		t.Errorf("normalize(typo) error = %v, want a suggestion of amazon.titan-text-express-v1", err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// ModelInfo describes a model clients can pass as PromptRequest.Model.
+type ModelInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Provider string `json:"provider,omitempty"`
+}
+
+type ModelsResponse struct {
+	Models []ModelInfo `json:"models"`
+}
+
+// modelsHandler lists the text foundation models available to the account,
+// followed by the configured inference profile aliases.
+func modelsHandler(svc *bedrock.Bedrock, profiles map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Invalid request method")
+			return
+		}
+
+		out, err := svc.ListFoundationModelsWithContext(r.Context(), &bedrock.ListFoundationModelsInput{
+			ByOutputModality: aws.String(bedrock.ModelModalityText),
+		})
+		if err != nil {
+			logf(r.Context(), "Error listing Bedrock models: %v", err)
+			writeError(w, http.StatusInternalServerError, APIError{
+				Code:         "bedrock_error",
+				Message:      "Failed to list Bedrock models",
+				AWSRequestID: awsRequestID(err),
+			})
+			return
+		}
+
+		response := ModelsResponse{Models: []ModelInfo{}}
+		for _, m := range out.ModelSummaries {
+			response.Models = append(response.Models, ModelInfo{
+				ID:       aws.StringValue(m.ModelId),
+				Name:     aws.StringValue(m.ModelName),
+				Provider: aws.StringValue(m.ProviderName),
+			})
+		}
+
+		aliases := make([]string, 0, len(profiles))
+		for alias := range profiles {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		for _, alias := range aliases {
+			response.Models = append(response.Models, ModelInfo{ID: alias})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
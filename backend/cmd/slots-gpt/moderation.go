@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultModerationPlaceholder replaces output flagged by moderation.
+const defaultModerationPlaceholder = "[Response withheld by content moderation]"
+
+// guardPromptTemplate asks the guard model for a single unsafety score.
+const guardPromptTemplate = `Rate how unsafe the following text is to show to a user, where 0 is completely safe and 1 is clearly harmful (violence, self-harm, sexual content involving minors, hate, instructions for weapons or crime). Answer with only the number.
+
+Text:
+%s`
+
+// outputModerator re-checks model output before it is returned, with local
+// patterns and, when a guard model is configured, a second Bedrock call.
+// It runs while the OUTPUT_MODERATION flag is on.
+type outputModerator struct {
+	flags       *featureFlags
+	svc         invoker
+	guardModel  string
+	threshold   float64
+	patterns    []*regexp.Regexp
+	placeholder string
+}
+
+// loadOutputModerator reads MODERATION_MODEL, MODERATION_THRESHOLD,
+// MODERATION_PATTERNS (a JSON array of regular expressions) and
+// MODERATION_PLACEHOLDER.
+func loadOutputModerator(flags *featureFlags, svc invoker, profiles map[string]string) (*outputModerator, error) {
+	m := &outputModerator{
+		flags:       flags,
+		svc:         svc,
+		threshold:   envFloat("MODERATION_THRESHOLD", 0.5),
+		placeholder: defaultModerationPlaceholder,
+	}
+	if placeholder := os.Getenv("MODERATION_PLACEHOLDER"); placeholder != "" {
+		m.placeholder = placeholder
+	}
+	if model := os.Getenv("MODERATION_MODEL"); model != "" {
+		guardModel, err := resolveModelID(model, profiles)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MODERATION_MODEL: %w", err)
+		}
+		m.guardModel = guardModel
+	}
+	if raw := os.Getenv("MODERATION_PATTERNS"); raw != "" {
+		var sources []string
+		if err := json.Unmarshal([]byte(raw), &sources); err != nil {
+			return nil, fmt.Errorf("invalid MODERATION_PATTERNS: %w", err)
+		}
+		for _, src := range sources {
+			re, err := regexp.Compile(src)
+			if err != nil {
+				return nil, fmt.Errorf("invalid MODERATION_PATTERNS entry %q: %w", src, err)
+			}
+			m.patterns = append(m.patterns, re)
+		}
+	}
+	return m, nil
+}
+
+// check reports whether output should be withheld. A guard model answer
+// that is not a number counts as flagged, so a confused guard fails closed.
+func (m *outputModerator) check(ctx context.Context, output string) (bool, error) {
+	if !m.flags.Enabled("OUTPUT_MODERATION") {
+		return false, nil
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(output) {
+			logf(ctx, "Output flagged by moderation pattern %q", re.String())
+			return true, nil
+		}
+	}
+	if m.guardModel == "" {
+		return false, nil
+	}
+
+	answer, err := invokeText(ctx, m.svc, m.guardModel, fmt.Sprintf(guardPromptTemplate, output))
+	if err != nil {
+		return false, fmt.Errorf("moderation: %w", err)
+	}
+	score, err := strconv.ParseFloat(strings.TrimSpace(answer), 64)
+	if err != nil {
+		logf(ctx, "Output flagged: guard model answered %q instead of a score", answer)
+		return true, nil
+	}
+	if score >= m.threshold {
+		logf(ctx, "Output flagged by guard model with score %.2f", score)
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// regionalInvoker is a Bedrock invoker bound to one region.
+type regionalInvoker struct {
+	invoker
+	Region string
+}
+
+// failoverInvoker sends invocations to the primary region and, when it
+// fails with a region-level error, to the secondary region.
+type failoverInvoker struct {
+	primary   regionalInvoker
+	secondary *regionalInvoker
+
+	failovers atomic.Int64
+}
+
+func (f *failoverInvoker) InvokeModelWithContext(ctx aws.Context, input *bedrock.InvokeModelInput, opts ...request.Option) (*bedrock.InvokeModelOutput, error) {
+	region := f.primary.Region
+	resp, err := f.primary.InvokeModelWithContext(ctx, input, opts...)
+	if err != nil && f.secondary != nil && isRegionalFailure(err) && ctx.Err() == nil {
+		f.failovers.Add(1)
+		logf(ctx, "Failing over from region %s to %s after error: %v", f.primary.Region, f.secondary.Region, err)
+		region = f.secondary.Region
+		resp, err = f.secondary.InvokeModelWithContext(ctx, input, opts...)
+	}
+	if info := invokeInfoFrom(ctx); info != nil {
+		info.setRegion(region)
+	}
+	return resp, err
+}
+
+// Failovers returns how many invocations have failed over so far.
+func (f *failoverInvoker) Failovers() int64 {
+	return f.failovers.Load()
+}
+
+// isRegionalFailure reports whether err suggests the region itself is
+// unhealthy: a server-side error or an unreachable endpoint, as opposed to a
+// problem with the request or the account.
+func isRegionalFailure(err error) bool {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		switch aerr.Code() {
+		case "ServiceUnavailableException", "InternalServerException", request.ErrCodeResponseTimeout:
+			return true
+		case request.ErrCodeRequestError:
+			return aerr.OrigErr() != nil && isTransientNetworkError(aerr.OrigErr())
+		}
+		var reqErr awserr.RequestFailure
+		return errors.As(err, &reqErr) && reqErr.StatusCode() >= 500
+	}
+	return isTransientNetworkError(err)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// invoker is the subset of the Bedrock client used to invoke models.
+type invoker interface {
+	InvokeModelWithContext(ctx aws.Context, input *bedrock.InvokeModelInput, opts ...request.Option) (*bedrock.InvokeModelOutput, error)
+}
+
+// retryPolicy configures how transient Bedrock failures are retried.
+type retryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+func loadRetryPolicy() retryPolicy {
+	return retryPolicy{
+		MaxRetries: envInt("BEDROCK_MAX_RETRIES", 3),
+		BaseDelay:  envDuration("BEDROCK_RETRY_BASE_DELAY", 200*time.Millisecond),
+		MaxDelay:   envDuration("BEDROCK_RETRY_MAX_DELAY", 5*time.Second),
+	}
+}
+
+// backoff returns the jittered delay before the given retry, doubling from
+// BaseDelay up to MaxDelay.
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << retry
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryingInvoker retries every invocation according to its policy.
+type retryingInvoker struct {
+	invoker
+	policy retryPolicy
+}
+
+func (r retryingInvoker) InvokeModelWithContext(ctx aws.Context, input *bedrock.InvokeModelInput, opts ...request.Option) (*bedrock.InvokeModelOutput, error) {
+	return invokeWithRetry(ctx, r.invoker, input, r.policy, opts...)
+}
+
+// invokeWithRetry invokes the model, retrying retryable errors with backoff
+// until the policy is exhausted or ctx is done.
+func invokeWithRetry(ctx context.Context, svc invoker, input *bedrock.InvokeModelInput, policy retryPolicy, opts ...request.Option) (*bedrock.InvokeModelOutput, error) {
+	for retry := 0; ; retry++ {
+		resp, err := svc.InvokeModelWithContext(ctx, input, opts...)
+		if err == nil || retry >= policy.MaxRetries || !isRetryable(err) {
+			return resp, err
+		}
+
+		timer := time.NewTimer(policy.backoff(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// invokeText invokes the model with prompt and returns the generated text.
+func invokeText(ctx context.Context, svc invoker, modelID, prompt string) (string, error) {
+	resp, err := svc.InvokeModelWithContext(ctx, &bedrock.InvokeModelInput{
+		InputText: aws.String(prompt),
+		ModelId:   aws.String(modelID),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.OutputText), nil
+}
+
+// retryableErrorCodes are the Bedrock error codes worth retrying.
+var retryableErrorCodes = map[string]bool{
+	"ThrottlingException":          true,
+	"TooManyRequestsException":     true,
+	"ServiceUnavailableException":  true,
+	"InternalServerException":      true,
+	"ModelNotReadyException":       true,
+	"ModelTimeoutException":        true,
+	request.ErrCodeResponseTimeout: true,
+}
+
+// isRetryable reports whether err is a transient failure: a throttling or
+// server-side AWS error, a 5xx response, or a network timeout or connection
+// reset, whether raw or wrapped in an SDK RequestError.
+func isRetryable(err error) bool {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		if retryableErrorCodes[aerr.Code()] {
+			return true
+		}
+		if reqErr, ok := aerr.(awserr.RequestFailure); ok && reqErr.StatusCode() >= 500 {
+			return true
+		}
+		if aerr.Code() == request.ErrCodeRequestError && aerr.OrigErr() != nil {
+			return isTransientNetworkError(aerr.OrigErr())
+		}
+		return false
+	}
+	return isTransientNetworkError(err)
+}
+
+// isTransientNetworkError reports whether err is a network timeout, a reset or
+// refused connection, or a connection closed mid-response.
+func isTransientNetworkError(err error) bool {
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// awsRequestID returns the AWS request ID of a failed Bedrock call, or ""
+// when err did not come from an AWS response.
+func awsRequestID(err error) string {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) {
+		return reqErr.RequestID()
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// fakeInvoker returns errs in order, then succeeds with output. It is safe
+// for concurrent use, as chunked requests invoke it from several goroutines.
+type fakeInvoker struct {
+	mu     sync.Mutex
+	errs   []error
+	output string
+	calls  int
+}
+
+func (f *fakeInvoker) InvokeModelWithContext(ctx aws.Context, input *bedrock.InvokeModelInput, opts ...request.Option) (*bedrock.InvokeModelOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &bedrock.InvokeModelOutput{OutputText: aws.String(f.output)}, nil
+}
+
+var testRetryPolicy = retryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond}
+
+func connReset() error {
+	return &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+}
+
+func validationError() error {
+	return awserr.NewRequestFailure(awserr.New("ValidationException", "malformed input", nil), 400, "req-1")
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection reset", connReset(), true},
+		{"connection reset in RequestError", awserr.New(request.ErrCodeRequestError, "send request failed", connReset()), true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{"unexpected EOF", fmt.Errorf("reading body: %w", io.ErrUnexpectedEOF), true},
+		{"throttling", awserr.NewRequestFailure(awserr.New("ThrottlingException", "slow down", nil), 429, "req-1"), true},
+		{"server error status", awserr.NewRequestFailure(awserr.New("SomethingElse", "boom", nil), 503, "req-1"), true},
+		{"validation", validationError(), false},
+		{"access denied", awserr.NewRequestFailure(awserr.New("AccessDeniedException", "no", nil), 403, "req-1"), false},
+		{"RequestError without network cause", awserr.New(request.ErrCodeRequestError, "send request failed", errors.New("bad url")), false},
+		{"plain error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvokeWithRetryRetriesNetworkErrors(t *testing.T) {
+	f := &fakeInvoker{errs: []error{connReset(), connReset()}, output: "ok"}
+	resp, err := invokeWithRetry(context.Background(), f, &bedrock.InvokeModelInput{}, testRetryPolicy)
+	if err != nil {
+		t.Fatalf("invokeWithRetry: %v", err)
+	}
+	if got := aws.StringValue(resp.OutputText); got != "ok" {
+		t.Errorf("output = %q, want %q", got, "ok")
+	}
+	if f.calls != 3 {
+		t.Errorf("calls = %d, want 3", f.calls)
+	}
+}
+
+func TestInvokeWithRetryDoesNotRetryValidationErrors(t *testing.T) {
+	f := &fakeInvoker{errs: []error{validationError()}}
+	_, err := invokeWithRetry(context.Background(), f, &bedrock.InvokeModelInput{}, testRetryPolicy)
+	if err == nil {
+		t.Fatal("invokeWithRetry succeeded, want ValidationException")
+	}
+	if f.calls != 1 {
+		t.Errorf("calls = %d, want 1", f.calls)
+	}
+}
+
+func TestInvokeWithRetryGivesUpAfterMaxRetries(t *testing.T) {
+	f := &fakeInvoker{errs: []error{connReset(), connReset(), connReset(), connReset(), connReset()}}
+	_, err := invokeWithRetry(context.Background(), f, &bedrock.InvokeModelInput{}, testRetryPolicy)
+	if err == nil {
+		t.Fatal("invokeWithRetry succeeded, want error")
+	}
+	if want := testRetryPolicy.MaxRetries + 1; f.calls != want {
+		t.Errorf("calls = %d, want %d", f.calls, want)
+	}
+}
+
+func TestInvokeWithRetryStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := &fakeInvoker{errs: []error{connReset(), connReset()}}
+	policy := retryPolicy{MaxRetries: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	if _, err := invokeWithRetry(ctx, f, &bedrock.InvokeModelInput{}, policy); err == nil {
+		t.Fatal("invokeWithRetry succeeded, want error")
+	}
+	if f.calls != 1 {
+		t.Errorf("calls = %d, want 1", f.calls)
+	}
+}
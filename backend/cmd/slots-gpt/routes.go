@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+)
+
+// modelRoute is one weighted backing model of a logical model name.
+type modelRoute struct {
+	Model  string `json:"model"`
+	Weight int    `json:"weight"`
+}
+
+// modelRoutes maps logical model names to the weighted models that serve
+// them, for splitting traffic between models.
+type modelRoutes map[string][]modelRoute
+
+// loadModelRoutes parses MODEL_ROUTES, a JSON object such as
+// {"chat": [{"model": "A", "weight": 70}, {"model": "B", "weight": 30}]}.
+func loadModelRoutes(raw string) (modelRoutes, error) {
+	routes := modelRoutes{}
+	if raw == "" {
+		return routes, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &routes); err != nil {
+		return nil, fmt.Errorf("invalid MODEL_ROUTES: %w", err)
+	}
+	for name, targets := range routes {
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("invalid MODEL_ROUTES: route %q has no models", name)
+		}
+		for _, t := range targets {
+			if t.Model == "" || t.Weight <= 0 {
+				return nil, fmt.Errorf("invalid MODEL_ROUTES: route %q needs a model and a positive weight for every entry", name)
+			}
+		}
+	}
+	return routes, nil
+}
+
+// pick returns a backing model for the logical name, chosen at random in
+// proportion to the weights. Names without a route are returned unchanged.
+// The package-level math/rand source is randomly seeded and safe for
+// concurrent use.
+func (routes modelRoutes) pick(name string) string {
+	targets, ok := routes[name]
+	if !ok {
+		return name
+	}
+	total := 0
+	for _, t := range targets {
+		total += t.Weight
+	}
+	n := rand.Intn(total)
+	for _, t := range targets {
+		if n < t.Weight {
+			return t.Model
+		}
+		n -= t.Weight
+	}
+	return targets[len(targets)-1].Model
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+)
+
+// promptSampler logs the full prompt and response of a random fraction of
+// requests at debug level, and of every failed request while sampling is on.
+type promptSampler struct {
+	rate float64
+}
+
+func loadPromptSampler() promptSampler {
+	rate := envFloat("LOG_SAMPLE_RATE", 0)
+	if rate < 0 {
+		rate = 0
+	}
+	if rate > 1 {
+		rate = 1
+	}
+	return promptSampler{rate: rate}
+}
+
+func (s promptSampler) log(ctx context.Context, modelID, prompt, output string, err error) {
+	if s.rate == 0 {
+		return
+	}
+	if err != nil {
+		debugf(ctx, "Sampled failed request: model=%s prompt=%q error=%q", modelID, prompt, err)
+		return
+	}
+	if rand.Float64() < s.rate {
+		debugf(ctx, "Sampled request: model=%s prompt=%q response=%q", modelID, prompt, output)
+	}
+}
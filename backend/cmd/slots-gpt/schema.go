@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// jsonSchema is the subset of JSON Schema supported for response_schema:
+// type, enum, properties, required, additionalProperties (as a boolean),
+// items, and the basic length and range keywords.
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+}
+
+// schemaTypes accepts "type" as either a single type name or a list of them.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("schema type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+// supportedKeywords are the keywords jsonSchema enforces.
+var supportedKeywords = map[string]bool{
+	"type": true, "enum": true, "properties": true, "required": true,
+	"additionalProperties": true, "items": true, "minItems": true, "maxItems": true,
+	"minLength": true, "maxLength": true, "minimum": true, "maximum": true,
+}
+
+// annotationKeywords do not constrain values, so they are accepted and
+// ignored.
+var annotationKeywords = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "default": true, "examples": true,
+}
+
+var schemaTypeNames = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// parseSchema decodes a response_schema. A missing schema only requires the
+// output to be valid JSON. Keywords that are not enforced, such as pattern,
+// anyOf or $ref, are rejected rather than ignored, so a schema is never
+// silently looser than the client wrote it.
+func parseSchema(raw json.RawMessage) (*jsonSchema, error) {
+	schema := &jsonSchema{}
+	if len(raw) == 0 {
+		return schema, nil
+	}
+	if err := json.Unmarshal(raw, schema); err != nil {
+		return nil, fmt.Errorf("invalid response_schema: %w", err)
+	}
+	if err := checkKeywords(raw, "response_schema"); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// checkKeywords rejects unsupported keywords and unknown type names in raw
+// and in the schemas nested under properties and items.
+func checkKeywords(raw json.RawMessage, path string) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keywords); err != nil {
+		return fmt.Errorf("invalid response_schema: %s must be an object", path)
+	}
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !supportedKeywords[name] && !annotationKeywords[name] {
+			return fmt.Errorf("invalid response_schema: keyword %q at %s is not supported", name, path)
+		}
+	}
+
+	// type and properties were already decoded by parseSchema, so their
+	// shapes are known to be valid here.
+	var types schemaTypes
+	if t, ok := keywords["type"]; ok {
+		json.Unmarshal(t, &types)
+	}
+	for _, t := range types {
+		if !schemaTypeNames[t] {
+			return fmt.Errorf("invalid response_schema: unknown type %q at %s", t, path)
+		}
+	}
+
+	if items, ok := keywords["items"]; ok {
+		if err := checkKeywords(items, path+".items"); err != nil {
+			return err
+		}
+	}
+	if props, ok := keywords["properties"]; ok {
+		var properties map[string]json.RawMessage
+		json.Unmarshal(props, &properties)
+		propNames := make([]string, 0, len(properties))
+		for name := range properties {
+			propNames = append(propNames, name)
+		}
+		sort.Strings(propNames)
+		for _, name := range propNames {
+			if err := checkKeywords(properties[name], path+".properties."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns a description of every way value, decoded with
+// encoding/json, violates the schema. path prefixes each message.
+func (s *jsonSchema) validate(value interface{}, path string) []string {
+	if s == nil {
+		return nil
+	}
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, joinTypes(s.Type), jsonValueType(value))}
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		return []string{fmt.Sprintf("%s: value is not one of the allowed enum values", path)}
+	}
+
+	var errs []string
+	switch v := value.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			errs = append(errs, fmt.Sprintf("%s: length %d is less than minLength %d", path, n, *s.MinLength))
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			errs = append(errs, fmt.Sprintf("%s: length %d is greater than maxLength %d", path, n, *s.MaxLength))
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			errs = append(errs, fmt.Sprintf("%s: %v is less than minimum %v", path, v, *s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			errs = append(errs, fmt.Sprintf("%s: %v is greater than maximum %v", path, v, *s.Maximum))
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			errs = append(errs, fmt.Sprintf("%s: %d items is less than minItems %d", path, len(v), *s.MinItems))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			errs = append(errs, fmt.Sprintf("%s: %d items is more than maxItems %d", path, len(v), *s.MaxItems))
+		}
+		for i, item := range v {
+			errs = append(errs, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					errs = append(errs, fmt.Sprintf("%s: unexpected property %q", path, name))
+				}
+				continue
+			}
+			errs = append(errs, prop.validate(v[name], path+"."+name)...)
+		}
+	}
+	return errs
+}
+
+func (s *jsonSchema) matchesType(value interface{}) bool {
+	actual := jsonValueType(value)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonValueType names the JSON Schema type of a decoded value, reporting
+// whole numbers as "integer".
+func jsonValueType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func joinTypes(types schemaTypes) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return fmt.Sprintf("one of %v", []string(types))
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func mustParseSchema(t *testing.T, raw string) *jsonSchema {
+	t.Helper()
+	schema, err := parseSchema(json.RawMessage(raw))
+	if err != nil {
+		t.Fatalf("parseSchema(%s): %v", raw, err)
+	}
+	return schema
+}
+
+func validateJSON(t *testing.T, schema *jsonSchema, value string) []string {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		t.Fatalf("invalid test value %s: %v", value, err)
+	}
+	return schema.validate(v, "$")
+}
+
+func TestParseSchemaRejectsUnsupportedKeywords(t *testing.T) {
+	for _, raw := range []string{
+		`{"type":"string","pattern":"^a$"}`,
+		`{"anyOf":[{"type":"string"}]}`,
+		`{"oneOf":[{"type":"string"}]}`,
+		`{"allOf":[{"type":"string"}]}`,
+		`{"$ref":"#/definitions/x"}`,
+		`{"type":"string","format":"email"}`,
+		`{"type":"object","properties":{"name":{"type":"string","pattern":"^a$"}}}`,
+		`{"type":"array","items":{"type":"number","multipleOf":2}}`,
+		`{"type":"strng"}`,
+		`{"type":"object","additionalProperties":{"type":"string"}}`,
+		`"string"`,
+	} {
+		if _, err := parseSchema(json.RawMessage(raw)); err == nil {
+			t.Errorf("parseSchema(%s) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestParseSchemaAcceptsAnnotations(t *testing.T) {
+	mustParseSchema(t, `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"T","description":"d","type":"object","properties":{"a":{"type":"string","default":"x","examples":["y"]}}}`)
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := mustParseSchema(t, `{
+		"type": "object",
+		"required": ["name", "tags"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "maxLength": 5},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"score": {"type": ["number", "null"]},
+			"color": {"enum": ["red", "green"]},
+			"tags": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}}
+		}
+	}`)
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"valid", `{"name":"ann","age":30,"score":null,"color":"red","tags":["a"]}`, nil},
+		{"number for integer type", `{"name":"ann","age":1.5,"tags":["a"]}`, []string{"$.age: expected integer, got number"}},
+		{"integer for number type", `{"name":"ann","score":3,"tags":["a"]}`, nil},
+		{"wrong root type", `[1]`, []string{"$: expected object, got array"}},
+		{"missing required", `{"name":"ann"}`, []string{`$: missing required property "tags"`}},
+		{"unexpected property", `{"name":"ann","tags":["a"],"extra":1}`, []string{`$: unexpected property "extra"`}},
+		{"string too short", `{"name":"a","tags":["a"]}`, []string{"$.name: length 1 is less than minLength 2"}},
+		{"string too long", `{"name":"annabel","tags":["a"]}`, []string{"$.name: length 7 is greater than maxLength 5"}},
+		{"below minimum", `{"name":"ann","age":-1,"tags":["a"]}`, []string{"$.age: -1 is less than minimum 0"}},
+		{"above maximum", `{"name":"ann","age":200,"tags":["a"]}`, []string{"$.age: 200 is greater than maximum 150"}},
+		{"not in enum", `{"name":"ann","color":"blue","tags":["a"]}`, []string{"$.color: value is not one of the allowed enum values"}},
+		{"too few items", `{"name":"ann","tags":[]}`, []string{"$.tags: 0 items is less than minItems 1"}},
+		{"too many items", `{"name":"ann","tags":["a","b","c"]}`, []string{"$.tags: 3 items is more than maxItems 2"}},
+		{"bad item", `{"name":"ann","tags":[1]}`, []string{"$.tags[0]: expected string, got integer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateJSON(t, schema, tt.value)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("validate(%s) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptySchemaAcceptsAnyJSON(t *testing.T) {
+	schema := mustParseSchema(t, "")
+	for _, value := range []string{`null`, `1`, `"x"`, `[1,"a"]`, `{"a":{"b":[]}}`} {
+		if got := validateJSON(t, schema, value); len(got) > 0 {
+			t.Errorf("validate(%s) = %q, want no violations", value, got)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// credentialEnvVars are the variables holding AWS credentials. Their values
+// must never be logged or echoed back.
+var credentialEnvVars = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"}
+
+// maskSecret hides a secret for display, keeping only the last four
+// characters of long values so operators can tell keys apart.
+func maskSecret(s string) string {
+	switch {
+	case s == "":
+		return ""
+	case len(s) < 16:
+		return "****"
+	default:
+		return "****" + s[len(s)-4:]
+	}
+}
+
+// scrubSecrets returns err with every occurrence of the given secrets
+// masked, for errors that may embed configuration values.
+func scrubSecrets(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	for _, secret := range secrets {
+		if secret != "" {
+			msg = strings.ReplaceAll(msg, secret, maskSecret(secret))
+		}
+	}
+	return errors.New(msg)
+}
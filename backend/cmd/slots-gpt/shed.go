@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/bedrock"
+)
+
+// maxLatencySamples bounds the memory used by the rolling latency window.
+const maxLatencySamples = 1024
+
+type latencySample struct {
+	at time.Time
+	d  time.Duration
+}
+
+// loadShedder tracks a rolling p99 of Bedrock latency and, while it is above
+// the threshold, rejects a fraction of incoming requests. It is disabled
+// when the threshold is zero.
+type loadShedder struct {
+	threshold time.Duration
+	fraction  float64
+	window    time.Duration
+
+	mu      sync.Mutex
+	samples []latencySample
+	next    int
+}
+
+func loadLoadShedder() *loadShedder {
+	return &loadShedder{
+		threshold: envDuration("SHED_LATENCY_THRESHOLD", 0),
+		fraction:  envFloat("SHED_FRACTION", 0.5),
+		window:    envDuration("SHED_WINDOW", time.Minute),
+	}
+}
+
+// observe records the latency of one Bedrock call.
+func (s *loadShedder) observe(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sample := latencySample{at: time.Now(), d: d}
+	if len(s.samples) < maxLatencySamples {
+		s.samples = append(s.samples, sample)
+		return
+	}
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % maxLatencySamples
+}
+
+// p99 returns the 99th percentile latency of the calls within the window, or
+// zero when there are none.
+func (s *loadShedder) p99() time.Duration {
+	cutoff := time.Now().Add(-s.window)
+	s.mu.Lock()
+	recent := make([]time.Duration, 0, len(s.samples))
+	for _, sample := range s.samples {
+		if sample.at.After(cutoff) {
+			recent = append(recent, sample.d)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(recent) == 0 {
+		return 0
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+	return recent[(len(recent)*99-1)/100]
+}
+
+// shedRate returns the fraction of requests currently being rejected.
+func (s *loadShedder) shedRate() float64 {
+	if s.threshold <= 0 || s.p99() <= s.threshold {
+		return 0
+	}
+	return s.fraction
+}
+
+// middleware rejects requests with 503 at the current shed rate.
+func (s *loadShedder) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rate := s.shedRate(); rate > 0 && rand.Float64() < rate {
+			w.Header().Set("Retry-After", "1")
+			writeAPIError(w, http.StatusServiceUnavailable, "overloaded", "Server is overloaded, try again later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// timedInvoker reports the latency of every model invocation to a shedder
+// and to the request's invokeInfo.
+type timedInvoker struct {
+	invoker
+	shedder *loadShedder
+}
+
+func (t timedInvoker) InvokeModelWithContext(ctx aws.Context, input *bedrock.InvokeModelInput, opts ...request.Option) (*bedrock.InvokeModelOutput, error) {
+	start := time.Now()
+	resp, err := t.invoker.InvokeModelWithContext(ctx, input, opts...)
+	elapsed := time.Since(start)
+	t.shedder.observe(elapsed)
+	if info := invokeInfoFrom(ctx); info != nil {
+		info.addBedrockTime(elapsed)
+	}
+	return resp, err
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// timingEntry is one named duration reported in the Server-Timing header.
+type timingEntry struct {
+	Name string
+	Desc string
+	Dur  time.Duration
+}
+
+// setServerTiming sets the Server-Timing header so clients can see where a
+// request's latency went.
+func setServerTiming(w http.ResponseWriter, entries ...timingEntry) {
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		parts[i] = fmt.Sprintf("%s;desc=%q;dur=%.1f", e.Name, e.Desc, float64(e.Dur)/float64(time.Millisecond))
+	}
+	w.Header().Set("Server-Timing", strings.Join(parts, ", "))
+}
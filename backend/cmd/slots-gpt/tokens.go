@@ -0,0 +1,9 @@
+package main
+
+import "unicode/utf8"
+
+// estimateTokens approximates the token count of s at four characters per
+// token, which is close enough for limit checks across model families.
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// usageTrailers are sent after the body when RESPONSE_TRAILERS is on. Token
+// counts are estimates, summed over chunks for chunked prompts.
+var usageTrailers = []string{"X-Input-Tokens", "X-Output-Tokens", "X-Duration-Ms"}
+
+// announceUsageTrailers declares the usage trailers. It must be called
+// before the body is written.
+func announceUsageTrailers(w http.ResponseWriter) {
+	w.Header().Set("Trailer", strings.Join(usageTrailers, ", "))
+}
+
+// writeUsageTrailers sets the trailers declared by announceUsageTrailers.
+// It must be called after the body is written.
+func writeUsageTrailers(w http.ResponseWriter, inputTokens, outputTokens int, elapsed time.Duration) {
+	h := w.Header()
+	h.Set("X-Input-Tokens", strconv.Itoa(inputTokens))
+	h.Set("X-Output-Tokens", strconv.Itoa(outputTokens))
+	h.Set("X-Duration-Ms", strconv.FormatInt(elapsed.Milliseconds(), 10))
+}
+
+// promptUsage estimates the tokens a request consumed.
+func promptUsage(prompt, output string, chunks []ChunkUsage) (int, int) {
+	if len(chunks) == 0 {
+		return estimateTokens(prompt), estimateTokens(output)
+	}
+	var inputTokens, outputTokens int
+	for _, c := range chunks {
+		inputTokens += c.InputTokens
+		outputTokens += c.OutputTokens
+	}
+	return inputTokens, outputTokens
+}
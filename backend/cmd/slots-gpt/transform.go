@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// PromptTransformer rewrites a request before it is sent to the model. A
+// transformer rejects a request by returning a *requestError, and can
+// annotate the response through the request's invokeInfo.
+type PromptTransformer interface {
+	Name() string
+	Transform(ctx context.Context, req PromptRequest) (PromptRequest, error)
+}
+
+// requestError rejects a request with a client-facing status, error code
+// and message.
+type requestError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *requestError) Error() string {
+	return e.Message
+}
+
+// transformChain applies its transformers in order.
+type transformChain []PromptTransformer
+
+func (c transformChain) apply(ctx context.Context, req PromptRequest) (PromptRequest, error) {
+	for _, t := range c {
+		var err error
+		if req, err = t.Transform(ctx, req); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+// defaultTransformOrder checks the client's own text for injections before
+// anything is added to it, then renders examples, and appends JSON mode
+// instructions last so they sit right before the model's answer.
+var defaultTransformOrder = []string{"injection", "few_shot", "json_mode"}
+
+// buildTransformChain orders the available transformers by PROMPT_TRANSFORMERS,
+// a comma-separated list of names, or by defaultTransformOrder. The list
+// only sets the order: it must name every available transformer exactly
+// once, since dropping one would silently disable a feature the request or
+// configuration asked for.
+func buildTransformChain(available ...PromptTransformer) (transformChain, error) {
+	byName := make(map[string]PromptTransformer, len(available))
+	for _, t := range available {
+		byName[t.Name()] = t
+	}
+	names := envList("PROMPT_TRANSFORMERS")
+	if len(names) == 0 {
+		names = defaultTransformOrder
+	}
+
+	var chain transformChain
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		t, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown prompt transformer %q in PROMPT_TRANSFORMERS", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("prompt transformer %q is listed more than once in PROMPT_TRANSFORMERS", name)
+		}
+		seen[name] = true
+		chain = append(chain, t)
+	}
+	for _, t := range available {
+		if !seen[t.Name()] {
+			return nil, fmt.Errorf("prompt transformer %q is missing from PROMPT_TRANSFORMERS; it sets the order and must list every transformer", t.Name())
+		}
+	}
+	return chain, nil
+}
+
+// injectionTransformer flags or rejects suspected prompt injections in the
+// prompt and examples, as configured by the feature flags.
+type injectionTransformer struct {
+	detector *injectionDetector
+	flags    *featureFlags
+}
+
+func (injectionTransformer) Name() string { return "injection" }
+
+func (t injectionTransformer) Transform(ctx context.Context, req PromptRequest) (PromptRequest, error) {
+	if !t.flags.Enabled("ENABLE_INJECTION_DETECTION") {
+		return req, nil
+	}
+	texts := []string{req.Prompt}
+	for _, ex := range req.Examples {
+		texts = append(texts, ex.Input, ex.Output)
+	}
+	pattern := t.detector.detect(texts...)
+	if pattern == "" {
+		return req, nil
+	}
+	logf(ctx, "Suspected prompt injection matching %q", pattern)
+	if t.flags.Enabled("INJECTION_BLOCK") {
+		return req, &requestError{http.StatusUnprocessableEntity, "prompt_rejected", "Prompt rejected as a suspected prompt injection"}
+	}
+	if info := invokeInfoFrom(ctx); info != nil {
+		info.setHeader("X-Injection-Suspected", "true")
+	}
+	return req, nil
+}
+
+// fewShotTransformer renders the request's examples into its prompt.
+type fewShotTransformer struct {
+	formatter *fewShotFormatter
+}
+
+func (fewShotTransformer) Name() string { return "few_shot" }
+
+func (t fewShotTransformer) Transform(ctx context.Context, req PromptRequest) (PromptRequest, error) {
+	if err := t.formatter.validate(req.Examples); err != nil {
+		return req, &requestError{http.StatusBadRequest, "invalid_examples", err.Error()}
+	}
+	prompt, err := t.formatter.format(req.Prompt, req.Examples)
+	if err != nil {
+		return req, err
+	}
+	req.Prompt, req.Examples = prompt, nil
+	return req, nil
+}
+
+// jsonModeTransformer appends the JSON output instructions in JSON mode.
+type jsonModeTransformer struct{}
+
+func (jsonModeTransformer) Name() string { return "json_mode" }
+
+func (jsonModeTransformer) Transform(ctx context.Context, req PromptRequest) (PromptRequest, error) {
+	if req.JSONMode {
+		req.Prompt = jsonModePrompt(req.Prompt, req.ResponseSchema)
+	}
+	return req, nil
+}
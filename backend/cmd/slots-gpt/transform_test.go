@@ -0,0 +1,52 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func testTransformers() []PromptTransformer {
+	return []PromptTransformer{
+		injectionTransformer{},
+		fewShotTransformer{},
+		jsonModeTransformer{},
+	}
+}
+
+func chainNames(chain transformChain) []string {
+	names := make([]string, len(chain))
+	for i, t := range chain {
+		names[i] = t.Name()
+	}
+	return names
+}
+
+func TestBuildTransformChainOrder(t *testing.T) {
+	for env, want := range map[string][]string{
+		"":                              {"injection", "few_shot", "json_mode"},
+		"few_shot, injection,json_mode": {"few_shot", "injection", "json_mode"},
+	} {
+		t.Setenv("PROMPT_TRANSFORMERS", env)
+		chain, err := buildTransformChain(testTransformers()...)
+		if err != nil {
+			t.Fatalf("PROMPT_TRANSFORMERS=%q: %v", env, err)
+		}
+		if got := chainNames(chain); !slices.Equal(got, want) {
+			t.Errorf("PROMPT_TRANSFORMERS=%q: order = %v, want %v", env, got, want)
+		}
+	}
+}
+
+func TestBuildTransformChainRejectsInvalidLists(t *testing.T) {
+	for _, env := range []string{
+		"few_shot,json_mode",                     // injection left out
+		"injection,json_mode",                    // few_shot left out
+		"injection,few_shot,json_mode,json_mode", // json_mode repeated
+		"injection,few_shot,json_mode,upper",     // unknown
+	} {
+		t.Setenv("PROMPT_TRANSFORMERS", env)
+		if _, err := buildTransformChain(testTransformers()...); err == nil {
+			t.Errorf("PROMPT_TRANSFORMERS=%q succeeded, want error", env)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// latestAPIVersion is used when a request names no version.
+const latestAPIVersion = "1"
+
+// requestDecoders decode each supported version of the send-prompt body into
+// the internal PromptRequest. A new version that changes the body shape gets
+// its own decoder translating to PromptRequest, and older ones keep working.
+var requestDecoders = map[string]func(body json.RawMessage) (PromptRequest, error){
+	"1": func(body json.RawMessage) (PromptRequest, error) {
+		var req PromptRequest
+		err := json.Unmarshal(body, &req)
+		return req, err
+	},
+}
+
+// versionError is returned for a missing or conflicting API version, as
+// opposed to a malformed body.
+type versionError string
+
+func (e versionError) Error() string { return string(e) }
+
+// decodePromptRequest decodes the body against the request schema version
+// named by the X-API-Version header or the body's version field, defaulting
+// to the latest. It returns the version used.
+func decodePromptRequest(header string, r io.Reader) (PromptRequest, string, error) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return PromptRequest{}, "", err
+	}
+
+	bodyVersion, err := envelopeVersion(body)
+	if err != nil {
+		return PromptRequest{}, "", err
+	}
+
+	version := strings.TrimSpace(header)
+	switch {
+	case version == "":
+		version = bodyVersion
+	case bodyVersion != "" && bodyVersion != version:
+		return PromptRequest{}, "", versionError(fmt.Sprintf("X-API-Version %q does not match body version %q", version, bodyVersion))
+	}
+	if version == "" {
+		version = latestAPIVersion
+	}
+
+	decode, ok := requestDecoders[version]
+	if !ok {
+		return PromptRequest{}, "", versionError(fmt.Sprintf("Unsupported API version %q; supported versions: %s", version, strings.Join(supportedAPIVersions(), ", ")))
+	}
+	req, err := decode(body)
+	return req, version, err
+}
+
+// envelopeVersion returns the body's version field, which may be a string
+// or an integer. It returns "" when the field is absent or the body is not
+// an object, which the version decoder then reports.
+func envelopeVersion(body json.RawMessage) (string, error) {
+	var envelope struct {
+		Version json.RawMessage `json:"version"`
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.Version == nil || string(envelope.Version) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(envelope.Version, &s); err == nil {
+		return strings.TrimSpace(s), nil
+	}
+	var n int64
+	if err := json.Unmarshal(envelope.Version, &n); err == nil {
+		return strconv.FormatInt(n, 10), nil
+	}
+	return "", versionError(fmt.Sprintf("Request field \"version\" must be a string or an integer, got %s", envelope.Version))
+}
+
+func supportedAPIVersions() []string {
+	versions := make([]string, 0, len(requestDecoders))
+	for version := range requestDecoders {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// writeDecodeError answers 400, or 413 for an oversized body, with a
+// message for a decodePromptRequest error.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	if verr, ok := err.(versionError); ok {
+		writeAPIError(w, http.StatusBadRequest, "unsupported_version", string(verr))
+		return
+	}
+	var sizeErr *http.MaxBytesError
+	if errors.As(err, &sizeErr) {
+		writeAPIError(w, http.StatusRequestEntityTooLarge, "request_too_large", decodeError(err))
+		return
+	}
+	writeAPIError(w, http.StatusBadRequest, "invalid_request", decodeError(err))
+}
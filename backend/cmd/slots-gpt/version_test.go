@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodePromptRequestVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "default to latest", body: `{"prompt":"hi","model":"m"}`, want: latestAPIVersion},
+		{name: "header", header: "1", body: `{"prompt":"hi","model":"m"}`, want: "1"},
+		{name: "string field", body: `{"version":"1","prompt":"hi","model":"m"}`, want: "1"},
+		{name: "integer field", body: `{"version":1,"prompt":"hi","model":"m"}`, want: "1"},
+		{name: "header and matching field", header: "1", body: `{"version":1,"prompt":"hi"}`, want: "1"},
+		{name: "unsupported header", header: "2", body: `{"prompt":"hi"}`, wantErr: true},
+		{name: "unsupported string field", body: `{"version":"2","prompt":"hi"}`, wantErr: true},
+		{name: "unsupported integer field", body: `{"version":2,"prompt":"hi"}`, wantErr: true},
+		{name: "fractional field", body: `{"version":1.5,"prompt":"hi"}`, wantErr: true},
+		{name: "boolean field", body: `{"version":true,"prompt":"hi"}`, wantErr: true},
+		{name: "object field", body: `{"version":{},"prompt":"hi"}`, wantErr: true},
+		{name: "header and field disagree", header: "1", body: `{"version":"2","prompt":"hi"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, version, err := decodePromptRequest(tt.header, strings.NewReader(tt.body))
+			if tt.wantErr {
+				var verr versionError
+				if !errors.As(err, &verr) {
+					t.Fatalf("err = %v, want a versionError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodePromptRequest: %v", err)
+			}
+			if version != tt.want {
+				t.Errorf("version = %q, want %q", version, tt.want)
+			}
+			if req.Prompt != "hi" {
+				t.Errorf("prompt = %q, want %q", req.Prompt, "hi")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// warmupPrompt is the cheapest prompt that still exercises a full invoke.
+const warmupPrompt = "ping"
+
+// warmer periodically invokes a model so the HTTP connections and
+// credentials are not cold when real traffic arrives after an idle period.
+type warmer struct {
+	svc      invoker
+	limiter  *modelLimiter
+	model    string
+	interval time.Duration
+}
+
+// loadWarmer reads WARMUP_INTERVAL and WARMUP_MODEL. It returns nil unless
+// both are set. The warmer calls svc directly rather than through the retry
+// and failover chain, so warm-ups are neither retried nor counted in the
+// load shedder's latency window.
+func loadWarmer(svc invoker, limiter *modelLimiter, profiles map[string]string) (*warmer, error) {
+	interval := envDuration("WARMUP_INTERVAL", 0)
+	model := os.Getenv("WARMUP_MODEL")
+	if interval <= 0 || model == "" {
+		return nil, nil
+	}
+	modelID, err := resolveModelID(model, profiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WARMUP_MODEL: %w", err)
+	}
+	return &warmer{svc: svc, limiter: limiter, model: modelID, interval: interval}, nil
+}
+
+func (w *warmer) start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			w.ping()
+		}
+	}()
+}
+
+// ping sends one warm-up invoke. It skips the round when the model has no
+// free concurrency slot, so it never queues ahead of real requests, and does
+// not retry. Failures are only logged and never affect health checks.
+func (w *warmer) ping() {
+	release, ok := w.limiter.tryAcquire(w.model)
+	if !ok {
+		return
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.interval)
+	defer cancel()
+	if _, err := invokeText(ctx, w.svc, w.model, warmupPrompt); err != nil {
+		log.Printf("WARNING: Bedrock warm-up invoke of %s failed: %v", w.model, err)
+		return
+	}
+	debugf(ctx, "Bedrock warm-up invoke of %s succeeded", w.model)
+}